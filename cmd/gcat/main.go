@@ -1,20 +1,72 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/timsexperiments/gcat/internal/cli"
 	"github.com/timsexperiments/gcat/internal/clipboard"
+	"github.com/timsexperiments/gcat/internal/config"
 	"github.com/timsexperiments/gcat/pkg/gcat"
+	"github.com/timsexperiments/gcat/pkg/lang"
+	"github.com/timsexperiments/gcat/pkg/redact"
+	"github.com/timsexperiments/gcat/pkg/tokenizer"
 )
 
 // version is set at build time via linker flags.
 // It should follow semantic versioning with a "v" prefix (e.g., v1.2.3)
 var version = "v0.0.0-dev"
 
-var copyOutput bool
+var (
+	copyOutput   bool
+	includeGlobs []string
+	excludeGlobs []string
+	selectAll    bool
+	outputFormat string
+	gitRef       string
+	gitIndex     bool
+	untracked    bool
+	binaryPolicy string
+	maxFileSize  string
+	maxTotalSize string
+	oversize     string
+	langTypes    []string
+	profileName  string
+	saveProfile  string
+	preselect    []string
+	treeMode     string
+	treeDepth    int
+	treeCollapse int
+	outline      bool
+	diffMode     string
+	history      int
+	historyPatch bool
+	redactSecret bool
+	noRedact     bool
+	allowSecret  bool
+	timeout      time.Duration
+	concurrency  int
+	noCache      bool
+	pruneAge     time.Duration
+
+	tokenizerName string
+	showTokens    bool
+	maxTokens     int
+	onExceed      string
+)
 
 func main() {
 	rootCmd := &cobra.Command{
@@ -32,41 +84,751 @@ func main() {
 		},
 	})
 
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect gcat configuration files",
+	}
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "show [source]",
+		Short: "Print the configuration merged from the user, project and $GCAT_CONFIG files",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   runConfigShow,
+	})
+	rootCmd.AddCommand(configCmd)
+
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the on-disk cache of remote repository clones",
+	}
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the cached repositories, most recently used first",
+		Args:  cobra.NoArgs,
+		Run:   runCacheList,
+	})
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the cached repositories that were not used recently",
+		Args:  cobra.NoArgs,
+		Run:   runCachePrune,
+	}
+	pruneCmd.Flags().DurationVar(&pruneAge, "older-than", 30*24*time.Hour, "Remove repositories not used for this long, such as 72h")
+	cacheCmd.AddCommand(pruneCmd)
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove every cached repository",
+		Args:  cobra.NoArgs,
+		Run:   runCacheClear,
+	})
+	rootCmd.AddCommand(cacheCmd)
+
+	diffCmd := &cobra.Command{
+		Use:   "diff <source> [<base>..<head>]",
+		Short: "Concatenate the files changed between two commits, or in the working tree",
+		Long: "Concatenate the files changed between two commits of a Git repository.\n\n" +
+			"The range defaults to HEAD. Without a head (\"main..\" or \"main\"), a local folder's\n" +
+			"working tree is compared against the base, and a remote repository's default branch.",
+		Args: cobra.RangeArgs(1, 2),
+		Run:  runDiff,
+	}
+	diffCmd.Flags().StringVar(&diffMode, "show", string(gcat.DiffPatch), fmt.Sprintf("What to write for each changed file (%s)", strings.Join(gcat.DiffModeNames(), ", ")))
+	diffCmd.Flags().StringVarP(&outputFormat, "format", "f", gcat.FormatDefault, fmt.Sprintf("Output format (%s)", strings.Join(gcat.FormatNames(), ", ")))
+	diffCmd.Flags().BoolVarP(&copyOutput, "copy", "c", false, "Copy output to clipboard instead of printing")
+	diffCmd.Flags().StringArrayVarP(&includeGlobs, "include", "i", nil, "Only include changed files matching the glob pattern (repeatable)")
+	diffCmd.Flags().StringArrayVarP(&excludeGlobs, "exclude", "e", nil, "Skip changed files matching the glob pattern (repeatable)")
+	diffCmd.Flags().BoolVar(&redactSecret, "redact", true, "Replace secrets such as private keys, API tokens and .env values with placeholders")
+	diffCmd.Flags().BoolVar(&noRedact, "no-redact", false, "Write files without redacting secrets")
+	diffCmd.Flags().BoolVar(&allowSecret, "allow-sensitive", false, "List and write files of the sensitive files deny list, such as id_rsa, *.pem and .npmrc")
+	diffCmd.Flags().BoolVar(&noCache, "no-cache", false, "Clone remote repositories into memory instead of fetching them into the on-disk cache")
+	diffCmd.Flags().StringVar(&binaryPolicy, "binary", string(gcat.BinarySkip), fmt.Sprintf("How to write the contents of binary files (%s)", strings.Join(gcat.BinaryPolicyNames(), ", ")))
+	rootCmd.AddCommand(diffCmd)
+
+	rootCmd.PersistentFlags().StringVarP(&profileName, "profile", "p", "", "Apply the named profile from the configuration files")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Give up when cloning and listing the files, or writing them, takes longer than this, such as 30s or 2m (0 means no limit)")
+	rootCmd.Flags().StringVar(&saveProfile, "save-profile", "", "Save the selected files as the named profile in the project configuration file")
 	rootCmd.Flags().BoolVarP(&copyOutput, "copy", "c", false, "Copy output to clipboard instead of printing")
+	rootCmd.Flags().StringArrayVarP(&includeGlobs, "include", "i", nil, "Select files matching the glob pattern without prompting (repeatable)")
+	rootCmd.Flags().StringArrayVarP(&excludeGlobs, "exclude", "e", nil, "Skip files matching the glob pattern without prompting (repeatable)")
+	rootCmd.Flags().StringArrayVar(&preselect, "preselect", nil, "Check files matching the glob pattern in the interactive prompt (repeatable)")
+	rootCmd.Flags().BoolVarP(&selectAll, "all", "a", false, "Select all files without prompting")
+	rootCmd.Flags().StringVarP(&outputFormat, "format", "f", gcat.FormatDefault, fmt.Sprintf("Output format (%s)", strings.Join(gcat.FormatNames(), ", ")))
+
+	rootCmd.Flags().StringArrayVarP(&langTypes, "type", "t", nil, fmt.Sprintf("Only list files whose language has the given type (%s; repeatable)", strings.Join(lang.TypeNames(), ", ")))
+	rootCmd.Flags().StringVar(&treeMode, "tree", "", "Write a directory tree of all files, or only the selected ones with --tree=selected, before the files")
+	rootCmd.Flags().Lookup("tree").NoOptDefVal = "all"
+	rootCmd.Flags().IntVar(&treeDepth, "tree-depth", 0, "Number of directory levels shown in the --tree (0 means no limit)")
+	rootCmd.Flags().IntVar(&treeCollapse, "tree-collapse", 50, "Summarize directories in the --tree holding more than this many files, none of them selected (0 means never)")
+	rootCmd.Flags().BoolVar(&outline, "outline", false, "Write Go files as their outline: declarations, signatures and doc comments with function bodies elided")
+	rootCmd.Flags().IntVar(&history, "history", 0, "Write the last N commits that touched each file after it, for Git sources (clones remote repositories in full)")
+	rootCmd.Flags().BoolVar(&historyPatch, "history-patch", false, "With --history, include the changes each commit made to the file")
+	rootCmd.Flags().BoolVar(&redactSecret, "redact", true, "Replace secrets such as private keys, API tokens and .env values with placeholders")
+	rootCmd.Flags().BoolVar(&noRedact, "no-redact", false, "Write files without redacting secrets")
+	rootCmd.Flags().BoolVar(&allowSecret, "allow-sensitive", false, "List and write files of the sensitive files deny list, such as id_rsa, *.pem and .npmrc")
+	rootCmd.Flags().StringVar(&binaryPolicy, "binary", string(gcat.BinarySkip), fmt.Sprintf("How to handle binary files (%s); skip hides them from selection", strings.Join(gcat.BinaryPolicyNames(), ", ")))
+	rootCmd.Flags().StringVar(&maxFileSize, "max-file-size", "", "Maximum bytes written per file, e.g. 512K or 1MB (no limit if empty)")
+	rootCmd.Flags().IntVar(&concurrency, "concurrency", 0, "Number of files read and processed at once; 1 streams them one at a time (0 means one per CPU)")
+	rootCmd.Flags().StringVar(&maxTotalSize, "max-total-size", "", "Maximum bytes of file contents written in total, e.g. 10MB (no limit if empty)")
+	rootCmd.Flags().StringVar(&oversize, "oversize", string(gcat.OversizeTruncate), fmt.Sprintf("What to do with files over a size limit (%s)", strings.Join(gcat.OversizePolicyNames(), ", ")))
 
-	if err := rootCmd.Execute(); err != nil {
+	rootCmd.Flags().BoolVar(&noCache, "no-cache", false, "Clone remote repositories into memory instead of fetching them into the on-disk cache")
+	rootCmd.Flags().StringVarP(&gitRef, "ref", "r", "", "Branch, tag or commit SHA to read from a Git repository (default branch if empty)")
+	rootCmd.Flags().BoolVar(&gitIndex, "git-index", false, "List the files tracked by Git (like git ls-files) when the local source is a Git working tree")
+	rootCmd.Flags().BoolVar(&untracked, "untracked", false, "With --git-index, also list untracked files that are not ignored (implies --git-index)")
+	rootCmd.Flags().StringVar(&tokenizerName, "tokenizer", tokenizer.HeuristicName, fmt.Sprintf("Tokenizer used to count tokens (%s)", strings.Join(tokenizer.Names(), ", ")))
+	rootCmd.Flags().BoolVar(&showTokens, "tokens", false, "Print per-file and total token counts to stderr")
+	rootCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Maximum number of tokens in the selected files (0 means no limit)")
+	rootCmd.Flags().StringVar(&onExceed, "on-exceed", "fail", "What to do when --max-tokens is exceeded: fail or truncate (drop files in output order)")
+
+	// Ctrl-C cancels cloning, listing and writing the files cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// withTimeout limits ctx to --timeout, when it is set.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// contextError describes err when it was caused by ctx being done, through
+// --timeout or an interrupt.
+func contextError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("timed out after %s", timeout)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return errors.New("interrupted")
+	}
+	return err
+}
+
 func runGcat(cmd *cobra.Command, args []string) {
 	source := args[0]
 
-	repo, err := gcat.OpenRepository(source)
+	binary, err := gcat.ParseBinaryPolicy(binaryPolicy)
 	if err != nil {
-		log.Fatalf("Error opening repository: %v", err)
+		log.Fatalf("Error selecting binary policy: %v", err)
 	}
 
-	files, err := repo.GetFiles()
+	oversizePolicy, err := gcat.ParseOversizePolicy(oversize)
 	if err != nil {
-		log.Fatalf("Error retrieving files: %v", err)
+		log.Fatalf("Error selecting oversize policy: %v", err)
 	}
 
-	selectedFiles, err := cli.SimpleSelector(files)
+	opts := []gcat.Option{
+		gcat.WithBinaryPolicy(binary),
+		gcat.WithOversizePolicy(oversizePolicy),
+		gcat.WithRef(gitRef),
+	}
+	if maxFileSize != "" {
+		n, err := cli.ParseSize(maxFileSize)
+		if err != nil {
+			log.Fatalf("Error: --max-file-size: %v", err)
+		}
+		opts = append(opts, gcat.WithMaxFileSize(n))
+	}
+	if maxTotalSize != "" {
+		n, err := cli.ParseSize(maxTotalSize)
+		if err != nil {
+			log.Fatalf("Error: --max-total-size: %v", err)
+		}
+		opts = append(opts, gcat.WithMaxTotalSize(n))
+	}
+	if gitIndex || untracked {
+		opts = append(opts, gcat.WithGitIndex(untracked))
+	}
+	if outline {
+		opts = append(opts, gcat.WithOutline())
+	}
+	if concurrency > 0 {
+		opts = append(opts, gcat.WithConcurrency(concurrency))
+	}
+	if history > 0 {
+		opts = append(opts, gcat.WithHistory(gcat.HistoryOptions{Commits: history, Patch: historyPatch}))
+	}
+	switch treeMode {
+	case "":
+	case "all", "selected":
+		opts = append(opts, gcat.WithTree(gcat.TreeOptions{
+			SelectedOnly: treeMode == "selected",
+			MaxDepth:     treeDepth,
+			Collapse:     treeCollapse,
+		}))
+	default:
+		log.Fatalf("Error: --tree must be all or selected, got %q", treeMode)
+	}
+
+	// The timeout applies to loading the files and, separately, to writing
+	// them, leaving out the time spent in the prompt.
+	ctx, cancel := withTimeout(cmd.Context())
+	defer cancel()
+	repo := openRepository(ctx, source, opts...)
+
+	cfg, profile := loadConfig(ctx, source, repo)
+	applyConfig(cmd, cfg)
+
+	if onExceed != "fail" && onExceed != "truncate" {
+		log.Fatalf("Error: --on-exceed must be fail or truncate, got %q", onExceed)
+	}
+
+	formatter, err := gcat.FormatterByName(outputFormat)
 	if err != nil {
-		log.Fatalf("Error during file selection: %v", err)
+		log.Fatalf("Error selecting output format: %v", err)
 	}
+	// The configuration is read from the source itself, so its options are
+	// applied to the repository once it is open.
+	gcat.WithFormatter(formatter)(repo)
+	gcat.WithRegisteredLanguages(cfg.Languages)(repo)
+	redactor := newRedactor(cmd)
+	gcat.WithRedactor(redactor)(repo)
+	sensitiveOption(cfg.Sensitive)(repo)
 
-	output, err := repo.ConcatFiles(selectedFiles)
+	files, err := repo.GetFilesContext(ctx)
 	if err != nil {
-		log.Fatalf("Error concatenating files: %v", err)
+		log.Fatalf("Error retrieving files: %v", contextError(ctx, err))
+	}
+	if len(langTypes) > 0 {
+		files = filterByType(repo, files)
+	}
+
+	// Globs from the flags or a profile select the files without prompting,
+	// as do the paths saved in a profile. Globs from the configuration files
+	// alone only narrow down the prompt.
+	flagGlobs := cmd.Flags().Changed("include") || cmd.Flags().Changed("exclude")
+	globSelects := selectAll || flagGlobs || len(profile.Include) > 0 || len(profile.Exclude) > 0
+	interactive := !globSelects && len(profile.Paths) == 0
+
+	var selectedFiles []string
+	if interactive {
+		if len(includeGlobs) > 0 || len(excludeGlobs) > 0 {
+			files, err = cli.FilterFiles(files, includeGlobs, excludeGlobs)
+			if err != nil {
+				log.Fatalf("Error filtering files: %v", err)
+			}
+		}
+		memoryKey := selectionKey(source)
+		preselected := preselectedFiles(memoryKey, files)
+		selectedFiles, err = cli.TreeSelector(files, fileStat(repo, loadTokenizer()), preselected)
+		if errors.Is(err, cli.ErrNotTerminal) {
+			selectedFiles, err = cli.SimpleSelector(files, preselected...)
+		}
+		if err != nil {
+			log.Fatalf("Error during file selection: %v", err)
+		}
+		if err := cli.SaveSelection(memoryKey, selectedFiles); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not remember the selection: %v\n", err)
+		}
+	} else {
+		sensitive := append(gcat.DefaultSensitivePatterns(), cfg.Sensitive...)
+		selectedFiles = selectNonInteractive(files, profile, globSelects, sensitive)
 	}
 
+	if saveProfile != "" {
+		saveSelection(source, selectedFiles, flagGlobs)
+	}
+
+	if interactive && !copyOutput {
+		fmt.Println("\n=== Concatenated Output ===")
+	}
+	writeOutput(cmd.Context(), func(ctx context.Context, w io.Writer) error {
+		if showTokens || maxTokens > 0 {
+			return applyTokenBudget(ctx, w, repo, selectedFiles)
+		}
+		return repo.ConcatTo(ctx, w, selectedFiles)
+	})
+	reportRedactions(redactor)
+}
+
+// writeOutput runs concat to copy its output to the clipboard with --copy,
+// or to stream it to stdout otherwise.
+func writeOutput(ctx context.Context, concat func(ctx context.Context, w io.Writer) error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
 	if copyOutput {
-		clipboard.WriteText(output)
+		var sb strings.Builder
+		if err := concat(ctx, &sb); err != nil {
+			fatalConcat(ctx, err)
+		}
+		clipboard.WriteText(sb.String())
 		fmt.Println("\nOutput copied to clipboard")
+		return
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	if err := concat(ctx, out); err != nil {
+		out.Flush()
+		fatalConcat(ctx, err)
+	}
+	fmt.Fprintln(out)
+	if err := out.Flush(); err != nil {
+		log.Fatalf("Error writing output: %v", err)
+	}
+}
+
+// fatalConcat exits with err, pointing at --allow-sensitive when a file of
+// the deny list was selected.
+func fatalConcat(ctx context.Context, err error) {
+	var sensitiveErr *gcat.SensitiveFileError
+	if errors.As(err, &sensitiveErr) {
+		log.Fatalf("Error: %v; use --allow-sensitive to write it", err)
+	}
+	log.Fatalf("Error concatenating files: %v", contextError(ctx, err))
+}
+
+// sensitiveOption returns the option applying --allow-sensitive, or the deny
+// list patterns of the configuration otherwise.
+func sensitiveOption(patterns []string) gcat.Option {
+	if allowSecret {
+		return gcat.WithAllowSensitive()
+	}
+	return gcat.WithSensitivePatterns(patterns...)
+}
+
+func runDiff(cmd *cobra.Command, args []string) {
+	source := args[0]
+	var spec string
+	if len(args) > 1 {
+		spec = args[1]
+	}
+	base, head, err := gcat.ParseRange(spec)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	mode, err := gcat.ParseDiffMode(diffMode)
+	if err != nil {
+		log.Fatalf("Error: --show: %v", err)
+	}
+	binary, err := gcat.ParseBinaryPolicy(binaryPolicy)
+	if err != nil {
+		log.Fatalf("Error selecting binary policy: %v", err)
+	}
+
+	opts := withDefaultAuth(source, []gcat.Option{gcat.WithBinaryPolicy(binary)})
+	ctx, cancel := withTimeout(cmd.Context())
+	defer cancel()
+	diff, err := gcat.OpenDiffContext(ctx, source, base, head, withCloneCache(source, opts)...)
+	if err != nil {
+		log.Fatalf("Error comparing revisions: %v", contextError(ctx, err))
+	}
+
+	// As for the main command, the configuration is read from the source,
+	// here at head, and applied once the diff is open.
+	cfg, _ := loadConfig(ctx, source, diff)
+	applyConfig(cmd, cfg)
+	formatter, err := gcat.FormatterByName(outputFormat)
+	if err != nil {
+		log.Fatalf("Error selecting output format: %v", err)
+	}
+	redactor := newRedactor(cmd)
+	diff.Apply(
+		gcat.WithFormatter(formatter),
+		gcat.WithRegisteredLanguages(cfg.Languages),
+		gcat.WithRedactor(redactor),
+		sensitiveOption(cfg.Sensitive),
+	)
+
+	changes := diff.Changes()
+	if len(includeGlobs) > 0 || len(excludeGlobs) > 0 {
+		paths := make([]string, len(changes))
+		for i, c := range changes {
+			paths[i] = c.Path
+		}
+		matched, err := cli.FilterFiles(paths, includeGlobs, excludeGlobs)
+		if err != nil {
+			log.Fatalf("Error filtering files: %v", err)
+		}
+		changes = slices.DeleteFunc(changes, func(c gcat.Change) bool {
+			return !slices.Contains(matched, c.Path)
+		})
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(os.Stderr, "No files changed")
+		return
+	}
+	for _, c := range changes {
+		fmt.Fprintf(os.Stderr, "%-8s  %s\n", c.Status, c.Path)
+	}
+
+	writeOutput(cmd.Context(), func(ctx context.Context, w io.Writer) error {
+		return diff.ConcatTo(ctx, w, changes, mode)
+	})
+	reportRedactions(redactor)
+}
+
+// newRedactor returns the redactor for --redact, or nil with --no-redact.
+func newRedactor(cmd *cobra.Command) *redact.Redactor {
+	if noRedact {
+		if cmd.Flags().Changed("redact") && redactSecret {
+			log.Fatalf("Error: --redact and --no-redact cannot be used together")
+		}
+		redactSecret = false
+	}
+	if !redactSecret {
+		return nil
+	}
+	return redact.New()
+}
+
+// reportRedactions prints what the redactor replaced to stderr.
+func reportRedactions(redactor *redact.Redactor) {
+	if redactor == nil {
+		return
+	}
+	summary := redactor.Summary()
+	if len(summary) == 0 {
+		return
+	}
+	total := 0
+	for _, file := range summary {
+		total += file.Total()
+	}
+	fmt.Fprintf(os.Stderr, "Redacted %d secret(s) from %d file(s); use --no-redact to keep them:\n", total, len(summary))
+	for _, file := range summary {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", file.Path, file)
+	}
+}
+
+// openRepository opens the repository or folder at source, using the default
+// Git credentials for remotes.
+func openRepository(ctx context.Context, source string, opts ...gcat.Option) gcat.Repository {
+	repo, err := gcat.OpenRepositoryContext(ctx, source, withCloneCache(source, withDefaultAuth(source, opts))...)
+	if err != nil {
+		log.Fatalf("Error opening repository: %v", contextError(ctx, err))
+	}
+	return repo
+}
+
+// withDefaultAuth adds the default Git credentials to opts when source is a
+// remote repository.
+func withDefaultAuth(source string, opts []gcat.Option) []gcat.Option {
+	if !gcat.IsRemoteURL(source) {
+		return opts
+	}
+	auth, err := gcat.DefaultAuth(source)
+	if err != nil {
+		log.Fatalf("Error loading Git credentials: %v", err)
+	}
+	return append(opts, gcat.WithAuth(auth))
+}
+
+// fileReader reads the files of a source, such as a gcat.Repository or a
+// gcat.Diff.
+type fileReader interface {
+	GetFileContentContext(ctx context.Context, filePath string) (string, error)
+}
+
+// loadConfig loads the configuration files, reading the project file from
+// the root of repo, and applies the --profile profile, which it returns.
+func loadConfig(ctx context.Context, source string, repo fileReader) (*config.Config, config.Profile) {
+	cfg, err := config.Load(source, func(name string) ([]byte, error) {
+		content, err := repo.GetFileContentContext(ctx, name)
+		return []byte(content), err
+	})
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+	var profile config.Profile
+	if profileName != "" {
+		profile, err = cfg.ApplyProfile(profileName)
+		if err != nil {
+			log.Fatalf("Error: --profile: %v", err)
+		}
+	}
+	return cfg, profile
+}
+
+// applyConfig uses the configured settings for the flags that were not given
+// on the command line.
+func applyConfig(cmd *cobra.Command, cfg *config.Config) {
+	flags := cmd.Flags()
+	if !flags.Changed("include") && len(cfg.Include) > 0 {
+		includeGlobs = cfg.Include
+	}
+	if !flags.Changed("exclude") && len(cfg.Exclude) > 0 {
+		excludeGlobs = cfg.Exclude
+	}
+	if !flags.Changed("format") && cfg.Format != "" {
+		outputFormat = cfg.Format
+	}
+	if !flags.Changed("tokenizer") && cfg.Tokenizer != "" {
+		tokenizerName = cfg.Tokenizer
+	}
+	if !flags.Changed("max-tokens") && cfg.MaxTokens != 0 {
+		maxTokens = cfg.MaxTokens
+	}
+	if !flags.Changed("on-exceed") && cfg.OnExceed != "" {
+		onExceed = cfg.OnExceed
+	}
+	if !flags.Changed("redact") && !flags.Changed("no-redact") && cfg.Redact != nil {
+		redactSecret = *cfg.Redact
+	}
+}
+
+// selectNonInteractive selects the paths saved in profile and, when
+// globSelects is set, the files matching the --include and --exclude globs.
+// It warns about saved paths that no longer exist, but keeps those matching
+// the sensitive patterns so that writing them is refused.
+func selectNonInteractive(files []string, profile config.Profile, globSelects bool, sensitive []string) []string {
+	selected, missing := cli.SelectPaths(files, profile.Paths)
+	for _, path := range missing {
+		if _, sensitive := gcat.IsSensitive(path, sensitive); sensitive {
+			// Selected so that writing it fails instead of silently
+			// leaving it out.
+			selected = append(selected, path)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s from profile %q no longer exists\n", path, profileName)
+	}
+
+	if globSelects {
+		// Matching nothing is reported below, unless the profile's paths
+		// selected files.
+		matched, err := cli.FilterFiles(files, includeGlobs, excludeGlobs)
+		if err != nil && !errors.Is(err, cli.ErrNoFilesMatched) {
+			log.Fatalf("Error filtering files: %v", err)
+		}
+		selected = append(selected, matched...)
+		sort.Strings(selected)
+		selected = slices.Compact(selected)
+	}
+
+	if len(selected) == 0 {
+		if profileName == "" {
+			log.Fatalf("Error: no files match --include/--exclude")
+		}
+		log.Fatalf("Error: none of the files in profile %q exist", profileName)
+	}
+	return selected
+}
+
+// selectionKey identifies source for remembering its last selection: the
+// absolute path of a folder, or the URL and ref of a Git repository.
+func selectionKey(source string) string {
+	if gcat.IsRemoteURL(source) {
+		if gitRef != "" {
+			return source + "#" + gitRef
+		}
+		return source
+	}
+	if abs, err := filepath.Abs(source); err == nil {
+		return abs
+	}
+	return source
+}
+
+// preselectedFiles returns the files to check in the prompt: those selected
+// the last time that still exist, and those matching the --preselect globs.
+func preselectedFiles(memoryKey string, files []string) []string {
+	remembered, err := cli.LoadSelection(memoryKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load the last selection: %v\n", err)
+	}
+	preselected, _ := cli.SelectPaths(files, remembered)
+
+	matched, err := cli.MatchFiles(files, preselect)
+	if err != nil {
+		log.Fatalf("Error: --preselect: %v", err)
+	}
+	preselected = append(preselected, matched...)
+	sort.Strings(preselected)
+	return slices.Compact(preselected)
+}
+
+// saveSelection stores the selection as the --save-profile profile in the
+// project configuration file: the --include and --exclude globs when they
+// made the selection, and the selected paths otherwise.
+func saveSelection(source string, files []string, flagGlobs bool) {
+	if gcat.IsRemoteURL(source) {
+		log.Fatalf("Error: --save-profile needs a local folder to write %s to", config.ProjectFiles[0])
+	}
+
+	var profile config.Profile
+	if flagGlobs {
+		profile.Include = includeGlobs
+		profile.Exclude = excludeGlobs
 	} else {
-		fmt.Println("\n=== Concatenated Output ===")
-		fmt.Println(output)
+		profile.Paths = files
+	}
+
+	path := config.ProjectFile(source)
+	if err := config.SaveProfile(path, saveProfile, profile); err != nil {
+		log.Fatalf("Error saving profile: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Saved profile %q to %s\n", saveProfile, path)
+}
+
+// cloneCache returns the on-disk cache of remote repository clones.
+func cloneCache() (*gcat.CloneCache, error) {
+	return gcat.DefaultCloneCache()
+}
+
+// withCloneCache adds the clone cache to the options of a remote source,
+// unless --no-cache is given. Without a user cache directory, the source is
+// cloned into memory.
+func withCloneCache(source string, opts []gcat.Option) []gcat.Option {
+	if noCache || !gcat.IsRemoteURL(source) {
+		return opts
+	}
+	cache, err := cloneCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not caching the clone: %v\n", err)
+		return opts
+	}
+	return append(opts, gcat.WithCloneCache(cache))
+}
+
+func runCacheList(cmd *cobra.Command, args []string) {
+	cache, err := cloneCache()
+	if err != nil {
+		log.Fatalf("Error locating the clone cache: %v", err)
+	}
+	repos, err := cache.List()
+	if err != nil {
+		log.Fatalf("Error listing cached repositories: %v", err)
+	}
+	if len(repos) == 0 {
+		fmt.Fprintf(os.Stderr, "No cached repositories in %s\n", cache.Dir)
+		return
+	}
+
+	var total int64
+	for _, repo := range repos {
+		fmt.Printf("%-10s  %s  %s\n", cli.FormatSize(repo.Size), repo.LastUsed.Format("2006-01-02 15:04"), repo.URL)
+		total += repo.Size
+	}
+	fmt.Fprintf(os.Stderr, "Cached repositories: %d, %s, in %s\n", len(repos), cli.FormatSize(total), cache.Dir)
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) {
+	cache, err := cloneCache()
+	if err != nil {
+		log.Fatalf("Error locating the clone cache: %v", err)
+	}
+	pruned, err := cache.Prune(pruneAge)
+	var freed int64
+	for _, repo := range pruned {
+		fmt.Fprintf(os.Stderr, "Removed %s (%s)\n", repo.URL, cli.FormatSize(repo.Size))
+		freed += repo.Size
+	}
+	if err != nil {
+		log.Fatalf("Error pruning cached repositories: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Pruned repositories: %d, freeing %s\n", len(pruned), cli.FormatSize(freed))
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) {
+	cache, err := cloneCache()
+	if err != nil {
+		log.Fatalf("Error locating the clone cache: %v", err)
+	}
+	if err := cache.Clear(); err != nil {
+		log.Fatalf("Error clearing the clone cache: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Cleared %s\n", cache.Dir)
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) {
+	source := "."
+	if len(args) > 0 {
+		source = args[0]
+	}
+
+	ctx, cancel := withTimeout(cmd.Context())
+	defer cancel()
+	cfg, _ := loadConfig(ctx, source, openRepository(ctx, source))
+	data, err := cfg.Marshal()
+	if err != nil {
+		log.Fatalf("Error encoding configuration: %v", err)
+	}
+
+	if len(cfg.Sources) == 0 {
+		fmt.Println("# No configuration files found")
+	}
+	for _, file := range cfg.Sources {
+		fmt.Printf("# %s\n", file)
+	}
+	if profileName != "" {
+		fmt.Printf("# profile: %s\n", profileName)
+	}
+	if string(data) != "{}\n" {
+		fmt.Print(string(data))
+	}
+}
+
+// filterByType keeps the files whose language has one of the --type types.
+func filterByType(repo gcat.Repository, files []string) []string {
+	types := make([]lang.Type, len(langTypes))
+	for i, name := range langTypes {
+		t, err := lang.ParseType(name)
+		if err != nil {
+			log.Fatalf("Error: --type: %v", err)
+		}
+		types[i] = t
+	}
+	files = gcat.FilesOfType(repo, files, types...)
+	if len(files) == 0 {
+		log.Fatalf("Error: no files of type %s", strings.Join(langTypes, ", "))
+	}
+	return files
+}
+
+// loadTokenizer returns the --tokenizer tokenizer.
+func loadTokenizer() tokenizer.Tokenizer {
+	tok, err := tokenizer.Get(tokenizerName)
+	if err != nil {
+		log.Fatalf("Error loading tokenizer: %v", err)
+	}
+	return tok
+}
+
+// fileStat returns the size and tokens of a file of repo for the selection
+// summary of the tree selector, counting tokens with the tokenizer --tokens
+// reports with.
+func fileStat(repo gcat.Repository, tok tokenizer.Tokenizer) func(filePath string) cli.FileStat {
+	return func(filePath string) cli.FileStat {
+		size, _ := repo.FileSize(filePath)
+		content, _ := repo.GetFileContent(filePath)
+		return cli.FileStat{Size: size, Tokens: tok.Count(content)}
+	}
+}
+
+// applyTokenBudget reports token counts and enforces --max-tokens, then
+// writes the files that fit to w. The files are counted as they are written,
+// so nothing is written when the budget is exceeded.
+func applyTokenBudget(ctx context.Context, w io.Writer, repo gcat.Repository, files []string) error {
+	tok := loadTokenizer()
+	counts, err := gcat.CountTokens(ctx, repo, files, tok)
+	if err != nil {
+		return err
+	}
+
+	if showTokens {
+		for _, count := range counts.Files {
+			fmt.Fprintf(os.Stderr, "%10d  %s\n", count.Tokens, count.Path)
+		}
+		fmt.Fprintf(os.Stderr, "%10d  total (%s)\n", counts.Total, tok.Name())
+	}
+
+	if maxTokens <= 0 || counts.Total <= maxTokens {
+		return counts.Concat(ctx, w, counts.Files)
+	}
+	if onExceed != "truncate" {
+		log.Fatalf("Error: selected files have %d tokens, exceeding the budget of %d", counts.Total, maxTokens)
+	}
+
+	kept, dropped := gcat.FitTokenBudget(counts.Files, maxTokens)
+	if len(kept) == 0 {
+		log.Fatalf("Error: %s alone exceeds the budget of %d tokens", dropped[0].Path, maxTokens)
+	}
+	for _, count := range dropped {
+		fmt.Fprintf(os.Stderr, "Dropped %s (%d tokens) to fit the budget of %d tokens\n", count.Path, count.Tokens, maxTokens)
 	}
+	return counts.Concat(ctx, w, kept)
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// TestMain runs gcat itself when the test binary is started by runCommand.
+func TestMain(m *testing.M) {
+	if os.Getenv("GCAT_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs gcat with args in a new process, with empty user
+// directories, and returns its standard output and error.
+func runCommand(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+
+	home := t.TempDir()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(),
+		"GCAT_TEST_MAIN=1",
+		"HOME="+home,
+		"XDG_CONFIG_HOME="+filepath.Join(home, ".config"),
+		"XDG_CACHE_HOME="+filepath.Join(home, ".cache"),
+		"GCAT_CONFIG=",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+// commitFiles writes files to the working tree at dir and commits them.
+func commitFiles(t *testing.T, dir string, files map[string]string) plumbing.Hash {
+	t.Helper()
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	for name, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+		_, err := wt.Add(name)
+		require.NoError(t, err)
+	}
+	signature := &object.Signature{Name: "gcat", Email: "gcat@example.com", When: time.Unix(1700000000, 0)}
+	hash, err := wt.Commit("commit", &git.CommitOptions{Author: signature})
+	require.NoError(t, err)
+	return hash
+}
+
+func TestDiff_ConfiguredSensitiveFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	base := commitFiles(t, dir, map[string]string{
+		".gcat.yaml": "sensitive:\n  - secrets/*.yaml\n",
+	})
+	head := commitFiles(t, dir, map[string]string{
+		"notes.txt":       "release notes",
+		"secrets/db.yaml": "host: db.internal",
+	})
+	revs := base.String() + ".." + head.String()
+
+	stdout, stderr, err := runCommand(t, "diff", dir, revs, "--show", "contents")
+	require.NoError(t, err, stderr)
+	assert.Contains(t, stdout, "release notes")
+	assert.NotContains(t, stdout, "db.internal")
+	assert.NotContains(t, stderr, "secrets/db.yaml")
+
+	stdout, stderr, err = runCommand(t, "diff", dir, revs, "--show", "contents", "--include", "secrets/**")
+	assert.Error(t, err)
+	assert.NotContains(t, stdout, "db.internal")
+	assert.Contains(t, stderr, "no files matched")
+
+	stdout, stderr, err = runCommand(t, "diff", dir, revs, "--show", "contents", "--allow-sensitive")
+	require.NoError(t, err, stderr)
+	assert.Contains(t, stdout, "db.internal")
+	assert.Contains(t, stderr, "secrets/db.yaml")
+}
+
+func TestSelectNonInteractive_NothingSelected(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gcat.yaml"), []byte(
+		"profiles:\n  gone:\n    paths: [removed.txt]\n  docs:\n    include: ['**/*.md']\n    paths: [notes.txt]\n"), 0o644))
+
+	_, stderr, err := runCommand(t, dir, "--include", "**/*.rs")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, "Error: no files match --include/--exclude")
+
+	_, stderr, err = runCommand(t, dir, "--profile", "gone")
+	assert.Error(t, err)
+	assert.Contains(t, stderr, `Error: none of the files in profile "gone" exist`)
+
+	stdout, stderr, err := runCommand(t, dir, "--profile", "docs")
+	require.NoError(t, err, stderr)
+	assert.Contains(t, stdout, "notes", "the saved paths are selected when the globs match nothing")
+}
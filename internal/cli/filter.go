@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ErrNoFilesMatched is returned by FilterFiles when no file matches the
+// patterns.
+var ErrNoFilesMatched = errors.New("no files matched the provided filters")
+
+// FilterFiles selects files without prompting the user.
+//
+// A file is selected when it matches at least one include pattern (or when no
+// include patterns are given) and matches none of the exclude patterns.
+// Patterns use doublestar syntax and are matched against slash-separated paths.
+// It returns ErrNoFilesMatched when no file is selected.
+func FilterFiles(files, include, exclude []string) ([]string, error) {
+	for _, pattern := range include {
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid include pattern %q", pattern)
+		}
+	}
+	for _, pattern := range exclude {
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid exclude pattern %q", pattern)
+		}
+	}
+
+	var selected []string
+	for _, file := range files {
+		path := filepath.ToSlash(file)
+		if len(include) > 0 && !matchAny(include, path) {
+			continue
+		}
+		if matchAny(exclude, path) {
+			continue
+		}
+		selected = append(selected, file)
+	}
+
+	if len(selected) == 0 {
+		return nil, ErrNoFilesMatched
+	}
+
+	sort.Strings(selected)
+	return selected, nil
+}
+
+// MatchFiles returns the files matching any of the doublestar patterns, such
+// as the --preselect globs. Unlike FilterFiles, it is not an error for
+// nothing to match.
+func MatchFiles(files, patterns []string) ([]string, error) {
+	for _, pattern := range patterns {
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	var matched []string
+	for _, file := range files {
+		if matchAny(patterns, filepath.ToSlash(file)) {
+			matched = append(matched, file)
+		}
+	}
+	return matched, nil
+}
+
+// SelectPaths selects the given paths from files, such as the paths saved in
+// a profile. It returns the selected files in sorted order along with the
+// paths that are not among files.
+func SelectPaths(files, paths []string) (selected, missing []string) {
+	available := make(map[string]bool, len(files))
+	for _, file := range files {
+		available[filepath.ToSlash(file)] = true
+	}
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		path = filepath.ToSlash(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if available[path] {
+			selected = append(selected, path)
+		} else {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(selected)
+	return selected, missing
+}
+
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if doublestar.MatchUnvalidated(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
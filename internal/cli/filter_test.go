@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterFiles(t *testing.T) {
+	t.Parallel()
+
+	files := []string{
+		"README.md",
+		"cmd/gcat/main.go",
+		"pkg/gcat/gcat.go",
+		"pkg/gcat/gcat_test.go",
+		"pkg/gcat/testdata/file1.txt",
+	}
+
+	tests := []struct {
+		name        string
+		include     []string
+		exclude     []string
+		want        []string
+		expectedErr string
+	}{
+		{
+			name: "no patterns selects everything",
+			want: files,
+		},
+		{
+			name:    "include only",
+			include: []string{"**/*.go"},
+			want: []string{
+				"cmd/gcat/main.go",
+				"pkg/gcat/gcat.go",
+				"pkg/gcat/gcat_test.go",
+			},
+		},
+		{
+			name:    "include and exclude",
+			include: []string{"**/*.go"},
+			exclude: []string{"**/*_test.go"},
+			want: []string{
+				"cmd/gcat/main.go",
+				"pkg/gcat/gcat.go",
+			},
+		},
+		{
+			name:    "exclude only",
+			exclude: []string{"pkg/**"},
+			want: []string{
+				"README.md",
+				"cmd/gcat/main.go",
+			},
+		},
+		{
+			name:    "repeated include patterns",
+			include: []string{"*.md", "pkg/gcat/testdata/*"},
+			want: []string{
+				"README.md",
+				"pkg/gcat/testdata/file1.txt",
+			},
+		},
+		{
+			name:        "nothing matches",
+			include:     []string{"**/*.rs"},
+			expectedErr: "no files matched the provided filters",
+		},
+		{
+			name:        "invalid include pattern",
+			include:     []string{"[a-"},
+			expectedErr: "invalid include pattern",
+		},
+		{
+			name:        "invalid exclude pattern",
+			exclude:     []string{"{a,"},
+			expectedErr: "invalid exclude pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			selected, err := FilterFiles(files, tt.include, tt.exclude)
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, selected)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, selected)
+		})
+	}
+}
+
+func TestSelectPaths(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"README.md", "api/handlers.go", "api/schema.sql", "main.go"}
+
+	selected, missing := SelectPaths(files, []string{"main.go", "api/handlers.go", "api/old.go", "main.go"})
+	assert.Equal(t, []string{"api/handlers.go", "main.go"}, selected)
+	assert.Equal(t, []string{"api/old.go"}, missing)
+
+	selected, missing = SelectPaths(files, nil)
+	assert.Empty(t, selected)
+	assert.Empty(t, missing)
+}
+
+func TestMatchFiles(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"README.md", "api/handlers.go", "api/schema.sql", "main.go"}
+
+	matched, err := MatchFiles(files, []string{"api/*.go", "*.md"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "api/handlers.go"}, matched)
+
+	matched, err = MatchFiles(files, []string{"docs/**"})
+	require.NoError(t, err)
+	assert.Empty(t, matched)
+
+	_, err = MatchFiles(files, []string{"[invalid"})
+	assert.EqualError(t, err, `invalid pattern "[invalid"`)
+}
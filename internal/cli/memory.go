@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// selectionDir returns the directory the last selections are remembered in,
+// "gcat/selections" under the user cache directory. Tests override it.
+var selectionDir = func() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "gcat", "selections"), nil
+}
+
+// rememberedSelection is the file a selection is remembered in.
+type rememberedSelection struct {
+	Source string   `json:"source"`
+	Files  []string `json:"files"`
+}
+
+// selectionPath returns the file the selection for source is remembered in.
+// Sources are hashed so that any path or URL makes a valid file name.
+func selectionPath(source string) (string, error) {
+	dir, err := selectionDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// LoadSelection returns the files last selected for source, such as the
+// absolute path of a folder or a repository URL and ref, or nil if there is
+// no remembered selection.
+func LoadSelection(source string) ([]string, error) {
+	path, err := selectionPath(source)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var remembered rememberedSelection
+	if err := json.Unmarshal(data, &remembered); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if remembered.Source != source {
+		return nil, nil
+	}
+	return remembered.Files, nil
+}
+
+// SaveSelection remembers files as the last selection for source.
+func SaveSelection(source string, files []string) error {
+	path, err := selectionPath(source)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rememberedSelection{Source: source, Files: files}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectionMemory(t *testing.T) {
+	realSelectionDir := selectionDir
+	defer func() { selectionDir = realSelectionDir }()
+	dir := filepath.Join(t.TempDir(), "selections")
+	selectionDir = func() (string, error) { return dir, nil }
+
+	files, err := LoadSelection("/src/project")
+	require.NoError(t, err)
+	assert.Nil(t, files, "nothing is remembered at first")
+
+	require.NoError(t, SaveSelection("/src/project", []string{"a.go", "b/c.go"}))
+	require.NoError(t, SaveSelection("https://example.com/repo.git#main", []string{"README.md"}))
+
+	files, err = LoadSelection("/src/project")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "b/c.go"}, files)
+
+	files, err = LoadSelection("https://example.com/repo.git#main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"README.md"}, files)
+
+	files, err = LoadSelection("https://example.com/repo.git#dev")
+	require.NoError(t, err)
+	assert.Nil(t, files, "selections are remembered per ref")
+
+	require.NoError(t, SaveSelection("/src/project", []string{"d.go"}))
+	files, err = LoadSelection("/src/project")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d.go"}, files, "the last selection replaces the previous one")
+
+	path, err := selectionPath("/src/project")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o644))
+	_, err = LoadSelection("/src/project")
+	assert.ErrorContains(t, err, "reading "+path)
+}
@@ -11,8 +11,9 @@ import (
 // it will behave as usual, but tests can override it.
 var askOne = survey.AskOne
 
-// SimpleSelector uses Survey’s MultiSelect to prompt the user.
-func SimpleSelector(files []string) ([]string, error) {
+// SimpleSelector uses Survey’s MultiSelect to prompt the user. The
+// preselected files start out checked.
+func SimpleSelector(files []string, preselected ...string) ([]string, error) {
 	sort.Strings(files)
 
 	var selected []string
@@ -20,6 +21,9 @@ func SimpleSelector(files []string) ([]string, error) {
 		Message: "Select files:",
 		Options: files,
 	}
+	if len(preselected) > 0 {
+		prompt.Default = preselected
+	}
 
 	if err := askOne(prompt, &selected); err != nil {
 		return nil, err
@@ -18,6 +18,7 @@ func TestSimpleSelector(t *testing.T) {
 		name        string
 		mockFn      func(p survey.Prompt, response interface{}, options ...survey.AskOpt) error
 		inputFiles  []string
+		preselected []string
 		expected    []string
 		expectedErr string
 	}{
@@ -33,6 +34,19 @@ func TestSimpleSelector(t *testing.T) {
 			inputFiles: []string{"file1.txt", "file2.txt", "file3.txt"},
 			expected:   []string{"file1.txt", "file2.txt"},
 		},
+		{
+			name: "Preselected files are checked",
+			mockFn: func(p survey.Prompt, response interface{}, options ...survey.AskOpt) error {
+				// Simulate a user confirming the default selection.
+				if sel, ok := response.(*[]string); ok {
+					*sel = p.(*survey.MultiSelect).Default.([]string)
+				}
+				return nil
+			},
+			inputFiles:  []string{"file1.txt", "file2.txt", "file3.txt"},
+			preselected: []string{"file3.txt"},
+			expected:    []string{"file3.txt"},
+		},
 		{
 			name: "No selection made",
 			mockFn: func(p survey.Prompt, response interface{}, options ...survey.AskOpt) error {
@@ -63,7 +77,7 @@ func TestSimpleSelector(t *testing.T) {
 			// Override askOne with the version specified by the test case.
 			askOne = tc.mockFn
 
-			selected, err := SimpleSelector(tc.inputFiles)
+			selected, err := SimpleSelector(tc.inputFiles, tc.preselected...)
 			if tc.expectedErr != "" {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tc.expectedErr,
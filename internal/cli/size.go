@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits maps the suffixes accepted by ParseSize to their multipliers.
+// Single letters are binary, like head(1) and du(1).
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1e9,
+	"gib": 1 << 30,
+}
+
+// ParseSize parses a byte count such as "512", "100K", "1.5MB" or "2GiB".
+// Suffixes are case-insensitive; KB, MB and GB are decimal, while K, M and G
+// and the KiB, MiB and GiB forms are binary.
+func ParseSize(s string) (int64, error) {
+	trimmed := strings.TrimSpace(s)
+	i := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(trimmed)
+	}
+	number, unit := trimmed[:i], strings.ToLower(strings.TrimSpace(trimmed[i:]))
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	size := value * multiplier
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(size), nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input       string
+		want        int64
+		expectedErr string
+	}{
+		{input: "0", want: 0},
+		{input: "512", want: 512},
+		{input: "512B", want: 512},
+		{input: "100k", want: 100 << 10},
+		{input: "100KB", want: 100_000},
+		{input: "100KiB", want: 100 << 10},
+		{input: "1.5M", want: 3 << 19},
+		{input: "2 MB", want: 2_000_000},
+		{input: "1g", want: 1 << 30},
+		{input: "1GiB", want: 1 << 30},
+		{input: "", expectedErr: "invalid size"},
+		{input: "MB", expectedErr: "invalid size"},
+		{input: "10TB", expectedErr: "invalid size"},
+		{input: "1.2.3K", expectedErr: "invalid size"},
+		{input: "-1", expectedErr: "invalid size"},
+		{input: "99999999999G", expectedErr: "too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseSize(tt.input)
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,469 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// treeNode is a file or directory shown by TreeSelector.
+type treeNode struct {
+	name     string
+	path     string
+	dir      bool
+	depth    int
+	parent   *treeNode
+	children []*treeNode
+	// stat is the size and tokens of a file, or nil until it is needed.
+	stat *FileStat
+}
+
+// FileStat is the size of a file in bytes and the number of tokens in it, as
+// shown in the selection summary of TreeSelector.
+type FileStat struct {
+	Size   int64
+	Tokens int
+}
+
+// buildTree arranges file paths into a tree of slash-separated paths.
+// Directories are listed before files, and both are sorted by name.
+func buildTree(files []string) (root *treeNode, leaves []*treeNode) {
+	root = &treeNode{dir: true}
+	dirs := map[string]*treeNode{"": root}
+
+	var dirOf func(dirPath string) *treeNode
+	dirOf = func(dirPath string) *treeNode {
+		if n, ok := dirs[dirPath]; ok {
+			return n
+		}
+		parent := dirOf(parentPath(dirPath))
+		n := &treeNode{name: path.Base(dirPath), path: dirPath, dir: true, depth: parent.depth + 1, parent: parent}
+		parent.children = append(parent.children, n)
+		dirs[dirPath] = n
+		return n
+	}
+
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		parent := dirOf(parentPath(file))
+		leaf := &treeNode{name: path.Base(file), path: file, depth: parent.depth + 1, parent: parent}
+		parent.children = append(parent.children, leaf)
+	}
+
+	var sortChildren func(n *treeNode)
+	sortChildren = func(n *treeNode) {
+		sort.Slice(n.children, func(i, j int) bool {
+			a, b := n.children[i], n.children[j]
+			if a.dir != b.dir {
+				return a.dir
+			}
+			return a.name < b.name
+		})
+		for _, c := range n.children {
+			if c.dir {
+				sortChildren(c)
+			} else {
+				leaves = append(leaves, c)
+			}
+		}
+	}
+	sortChildren(root)
+	return root, leaves
+}
+
+func parentPath(p string) string {
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[:i]
+	}
+	return ""
+}
+
+// fuzzyMatch reports whether the characters of query appear in s in order,
+// ignoring case.
+func fuzzyMatch(query, s string) bool {
+	s = strings.ToLower(s)
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// treeAction is the outcome of a key press in the tree selector.
+type treeAction int
+
+const (
+	actionNone treeAction = iota
+	actionConfirm
+	actionCancel
+)
+
+// treeModel holds the state of the tree selector: which directories are
+// expanded, which files are selected, the filter and the cursor.
+type treeModel struct {
+	root     *treeNode
+	leaves   []*treeNode
+	fileStat func(filePath string) FileStat
+
+	expanded map[*treeNode]bool
+	selected map[*treeNode]bool
+	query    string
+	// matches holds the files matching query and the directories containing
+	// them. It is nil when there is no query.
+	matches map[*treeNode]bool
+
+	rows   []*treeNode
+	cursor int
+	offset int
+
+	count  int
+	bytes  int64
+	tokens int
+}
+
+// newTreeModel returns a selector for files with all directories collapsed.
+// fileStat reports the size and tokens of a file for the selection summary;
+// it may be nil.
+func newTreeModel(files []string, fileStat func(filePath string) FileStat) *treeModel {
+	root, leaves := buildTree(files)
+	m := &treeModel{
+		root:     root,
+		leaves:   leaves,
+		fileStat: fileStat,
+		expanded: map[*treeNode]bool{root: true},
+		selected: map[*treeNode]bool{},
+	}
+	m.refresh()
+	return m
+}
+
+// preselect selects the given files and expands the directories containing
+// them. Paths that are not in the tree are ignored.
+func (m *treeModel) preselect(paths []string) {
+	wanted := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		wanted[filepath.ToSlash(p)] = true
+	}
+	for _, leaf := range m.leaves {
+		if !wanted[leaf.path] {
+			continue
+		}
+		m.setSelected(leaf, true)
+		for n := leaf.parent; n != nil; n = n.parent {
+			m.expanded[n] = true
+		}
+	}
+	m.refresh()
+}
+
+// refresh recomputes the visible rows after the filter or the expanded
+// directories change, keeping the cursor on the same node when possible.
+func (m *treeModel) refresh() {
+	var current *treeNode
+	if m.cursor < len(m.rows) {
+		current = m.rows[m.cursor]
+	}
+
+	m.matches = nil
+	if m.query != "" {
+		m.matches = map[*treeNode]bool{}
+		for _, leaf := range m.leaves {
+			if !fuzzyMatch(m.query, leaf.path) {
+				continue
+			}
+			for n := leaf; n != nil && !m.matches[n]; n = n.parent {
+				m.matches[n] = true
+			}
+		}
+	}
+
+	m.rows = m.rows[:0]
+	var walk func(n *treeNode)
+	walk = func(n *treeNode) {
+		for _, c := range n.children {
+			if m.matches != nil && !m.matches[c] {
+				continue
+			}
+			m.rows = append(m.rows, c)
+			if c.dir && (m.matches != nil || m.expanded[c]) {
+				walk(c)
+			}
+		}
+	}
+	walk(m.root)
+
+	m.cursor = 0
+	for i, n := range m.rows {
+		if n == current {
+			m.cursor = i
+			break
+		}
+	}
+}
+
+// files returns the files under n, or n itself if it is a file, that match
+// the filter.
+func (m *treeModel) files(n *treeNode) []*treeNode {
+	if !n.dir {
+		return []*treeNode{n}
+	}
+	var files []*treeNode
+	for _, c := range n.children {
+		if m.matches == nil || m.matches[c] {
+			files = append(files, m.files(c)...)
+		}
+	}
+	return files
+}
+
+// toggle selects the files under n that match the filter, or deselects them
+// if they are all selected already.
+func (m *treeModel) toggle(n *treeNode) {
+	files := m.files(n)
+	all := true
+	for _, f := range files {
+		all = all && m.selected[f]
+	}
+	for _, f := range files {
+		m.setSelected(f, !all)
+	}
+}
+
+func (m *treeModel) setSelected(f *treeNode, selected bool) {
+	if m.selected[f] == selected {
+		return
+	}
+	if f.stat == nil {
+		f.stat = &FileStat{}
+		if m.fileStat != nil {
+			*f.stat = m.fileStat(filepath.FromSlash(f.path))
+		}
+	}
+	if selected {
+		m.selected[f] = true
+		m.count++
+		m.bytes += f.stat.Size
+		m.tokens += f.stat.Tokens
+	} else {
+		delete(m.selected, f)
+		m.count--
+		m.bytes -= f.stat.Size
+		m.tokens -= f.stat.Tokens
+	}
+}
+
+// checkbox returns the check box of n: "[x]" when all its files are selected,
+// "[-]" when some are and "[ ]" when none are.
+func (m *treeModel) checkbox(n *treeNode) string {
+	var selected, total int
+	for _, f := range m.files(n) {
+		total++
+		if m.selected[f] {
+			selected++
+		}
+	}
+	switch {
+	case total > 0 && selected == total:
+		return "[x]"
+	case selected > 0:
+		return "[-]"
+	}
+	return "[ ]"
+}
+
+func (m *treeModel) move(delta int) {
+	m.cursor = max(0, min(len(m.rows)-1, m.cursor+delta))
+}
+
+// handle applies a key press. page is the number of rows shown at once.
+func (m *treeModel) handle(k key, page int) treeAction {
+	var current *treeNode
+	if m.cursor < len(m.rows) {
+		current = m.rows[m.cursor]
+	}
+
+	switch k.code {
+	case keyEnter:
+		return actionConfirm
+	case keyCtrlC:
+		return actionCancel
+	case keyEsc:
+		if m.query == "" {
+			return actionCancel
+		}
+		m.query = ""
+		m.refresh()
+	case keyUp:
+		m.move(-1)
+	case keyDown:
+		m.move(1)
+	case keyPageUp:
+		m.move(-page)
+	case keyPageDown:
+		m.move(page)
+	case keyHome:
+		m.cursor = 0
+	case keyEnd:
+		m.move(len(m.rows))
+	case keyRight:
+		if current != nil && current.dir && m.matches == nil {
+			if m.expanded[current] {
+				m.move(1)
+			} else {
+				m.expanded[current] = true
+				m.refresh()
+			}
+		}
+	case keyLeft:
+		if current == nil {
+			break
+		}
+		if current.dir && m.expanded[current] && m.matches == nil {
+			delete(m.expanded, current)
+			m.refresh()
+			break
+		}
+		for i, n := range m.rows {
+			if n == current.parent {
+				m.cursor = i
+			}
+		}
+	case keyTab:
+		if current != nil {
+			m.toggle(current)
+			m.move(1)
+		}
+	case keyCtrlA:
+		m.toggle(m.root)
+	case keyCtrlU:
+		m.query = ""
+		m.refresh()
+	case keyBackspace:
+		if m.query != "" {
+			_, n := utf8.DecodeLastRuneInString(m.query)
+			m.query = m.query[:len(m.query)-n]
+			m.refresh()
+		}
+	case keyRune:
+		if k.r == ' ' {
+			if current != nil {
+				m.toggle(current)
+			}
+			break
+		}
+		m.query += string(k.r)
+		m.refresh()
+	}
+	return actionNone
+}
+
+// selection returns the selected files in sorted order.
+func (m *treeModel) selection() []string {
+	var files []string
+	for _, leaf := range m.leaves {
+		if m.selected[leaf] {
+			files = append(files, filepath.FromSlash(leaf.path))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// summary describes the selection: its file count, size and tokens.
+func (m *treeModel) summary() string {
+	noun := "files"
+	if m.count == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("%d %s selected, %s, ~%s tokens", m.count, noun, FormatSize(m.bytes), formatCount(int64(m.tokens)))
+}
+
+const treeHelp = "↑/↓ move  ←/→ collapse/expand  space select  ctrl-a all  type to filter  enter confirm  esc cancel"
+
+// render draws the selector as lines of at most width columns filling
+// height rows: a help line, the filter, the tree and the selection summary.
+func (m *treeModel) render(width, height int) []string {
+	page := max(1, height-3)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+page {
+		m.offset = m.cursor - page + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.rows)-page))
+
+	lines := []string{
+		truncateLine(treeHelp, width),
+		truncateLine("> "+m.query, width),
+	}
+	for i := m.offset; i < m.offset+page; i++ {
+		if i >= len(m.rows) {
+			lines = append(lines, "")
+			continue
+		}
+		line := truncateLine(m.row(m.rows[i]), width)
+		if i == m.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		lines = append(lines, line)
+	}
+
+	status := m.summary()
+	if m.matches != nil {
+		status += fmt.Sprintf(" (%d matching)", len(m.files(m.root)))
+	}
+	return append(lines, truncateLine(status, width))
+}
+
+func (m *treeModel) row(n *treeNode) string {
+	indent := strings.Repeat("  ", n.depth-1)
+	switch {
+	case !n.dir:
+		return fmt.Sprintf("%s %s  %s", m.checkbox(n), indent, n.name)
+	case m.matches != nil || m.expanded[n]:
+		return fmt.Sprintf("%s %s▾ %s/", m.checkbox(n), indent, n.name)
+	default:
+		return fmt.Sprintf("%s %s▸ %s/", m.checkbox(n), indent, n.name)
+	}
+}
+
+// truncateLine cuts s to width runes, marking the cut with an ellipsis.
+func truncateLine(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// FormatSize formats a byte count with a binary unit, such as "12.3 KiB".
+func FormatSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	for _, unit := range []string{"KiB", "MiB", "GiB"} {
+		value /= 1024
+		if value < 1024 || unit == "GiB" {
+			return fmt.Sprintf("%.1f %s", value, unit)
+		}
+	}
+	return ""
+}
+
+// formatCount abbreviates large counts, such as "12.3K".
+func formatCount(n int64) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d", n)
+	case n < 1000000:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%.1fM", float64(n)/1e6)
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var treeFiles = []string{
+	"README.md",
+	"cmd/gcat/main.go",
+	"go.mod",
+	"pkg/gcat/gcat.go",
+	"pkg/gcat/gcat_test.go",
+	"pkg/lang/lang.go",
+}
+
+func treeFileStat(filePath string) FileStat {
+	return FileStat{Size: int64(len(filePath)) * 100, Tokens: len(filePath)}
+}
+
+// rowNames returns the visible rows as "name/" for directories and "name"
+// for files, indented by depth.
+func rowNames(m *treeModel) []string {
+	names := make([]string, len(m.rows))
+	for i, n := range m.rows {
+		names[i] = strings.Repeat(" ", n.depth-1) + n.name
+		if n.dir {
+			names[i] += "/"
+		}
+	}
+	return names
+}
+
+// cursorTo moves the cursor to the row of path.
+func cursorTo(t *testing.T, m *treeModel, path string) {
+	t.Helper()
+	for i, n := range m.rows {
+		if n.path == path {
+			m.cursor = i
+			return
+		}
+	}
+	t.Fatalf("%s is not visible", path)
+}
+
+func TestTreeModel_ExpandCollapse(t *testing.T) {
+	t.Parallel()
+
+	m := newTreeModel(treeFiles, nil)
+	assert.Equal(t, []string{"cmd/", "pkg/", "README.md", "go.mod"}, rowNames(m))
+
+	cursorTo(t, m, "pkg")
+	m.handle(key{code: keyRight}, 10)
+	assert.Equal(t, []string{"cmd/", "pkg/", " gcat/", " lang/", "README.md", "go.mod"}, rowNames(m))
+
+	m.handle(key{code: keyRight}, 10)
+	assert.Equal(t, "pkg/gcat", m.rows[m.cursor].path, "right on an expanded directory moves into it")
+	m.handle(key{code: keyRight}, 10)
+	assert.Equal(t, []string{"cmd/", "pkg/", " gcat/", "  gcat.go", "  gcat_test.go", " lang/", "README.md", "go.mod"}, rowNames(m))
+
+	cursorTo(t, m, "pkg/gcat/gcat.go")
+	m.handle(key{code: keyLeft}, 10)
+	assert.Equal(t, "pkg/gcat", m.rows[m.cursor].path, "left on a file moves to its directory")
+	m.handle(key{code: keyLeft}, 10)
+	assert.Equal(t, []string{"cmd/", "pkg/", " gcat/", " lang/", "README.md", "go.mod"}, rowNames(m))
+	assert.Equal(t, "pkg/gcat", m.rows[m.cursor].path)
+}
+
+func TestTreeModel_ToggleDirectory(t *testing.T) {
+	t.Parallel()
+
+	m := newTreeModel(treeFiles, treeFileStat)
+
+	cursorTo(t, m, "pkg")
+	m.handle(key{code: keyRune, r: ' '}, 10)
+	assert.Equal(t, []string{"pkg/gcat/gcat.go", "pkg/gcat/gcat_test.go", "pkg/lang/lang.go"}, m.selection())
+	assert.Equal(t, "[x]", m.checkbox(m.rows[m.cursor]))
+	assert.Equal(t, "[-]", m.checkbox(m.root))
+	assert.Equal(t, 3, m.count)
+	assert.Equal(t, int64(16+21+16)*100, m.bytes)
+	assert.Equal(t, 16+21+16, m.tokens)
+
+	m.handle(key{code: keyRight}, 10)
+	cursorTo(t, m, "pkg/lang")
+	m.handle(key{code: keyRune, r: ' '}, 10)
+	assert.Equal(t, []string{"pkg/gcat/gcat.go", "pkg/gcat/gcat_test.go"}, m.selection())
+
+	cursorTo(t, m, "pkg")
+	assert.Equal(t, "[-]", m.checkbox(m.rows[m.cursor]))
+	m.handle(key{code: keyRune, r: ' '}, 10)
+	assert.Len(t, m.selection(), 3, "toggling a partly selected directory selects all of it")
+	m.handle(key{code: keyRune, r: ' '}, 10)
+	assert.Empty(t, m.selection())
+	assert.Equal(t, 0, m.count)
+	assert.Equal(t, int64(0), m.bytes)
+	assert.Equal(t, 0, m.tokens)
+
+	m.handle(key{code: keyCtrlA}, 10)
+	assert.Equal(t, []string{"README.md", "cmd/gcat/main.go", "go.mod", "pkg/gcat/gcat.go", "pkg/gcat/gcat_test.go", "pkg/lang/lang.go"}, m.selection())
+}
+
+func TestTreeModel_Preselect(t *testing.T) {
+	t.Parallel()
+
+	m := newTreeModel(treeFiles, treeFileStat)
+	m.preselect([]string{"pkg/gcat/gcat.go", "go.mod", "deleted.go"})
+
+	assert.Equal(t, []string{"go.mod", "pkg/gcat/gcat.go"}, m.selection())
+	assert.Equal(t, 2, m.count)
+	assert.Equal(t, []string{"cmd/", "pkg/", " gcat/", "  gcat.go", "  gcat_test.go", " lang/", "README.md", "go.mod"}, rowNames(m), "directories of preselected files are expanded")
+}
+
+func TestTreeModel_Filter(t *testing.T) {
+	t.Parallel()
+
+	m := newTreeModel(treeFiles, nil)
+	for _, r := range "gcgo" {
+		m.handle(key{code: keyRune, r: r}, 10)
+	}
+	assert.Equal(t, "gcgo", m.query)
+	assert.Equal(t, []string{"cmd/", " gcat/", "  main.go", "pkg/", " gcat/", "  gcat.go", "  gcat_test.go"}, rowNames(m))
+
+	m.handle(key{code: keyCtrlU}, 10)
+	for _, r := range "test" {
+		m.handle(key{code: keyRune, r: r}, 10)
+	}
+	assert.Equal(t, []string{"pkg/", " gcat/", "  gcat_test.go"}, rowNames(m))
+
+	cursorTo(t, m, "pkg")
+	m.handle(key{code: keyRune, r: ' '}, 10)
+	assert.Equal(t, []string{"pkg/gcat/gcat_test.go"}, m.selection(), "toggling a directory only selects the matching files")
+
+	m.handle(key{code: keyBackspace}, 10)
+	assert.Equal(t, "tes", m.query)
+	assert.Equal(t, "pkg", m.rows[m.cursor].path, "the cursor stays on the same node")
+
+	m.handle(key{code: keyEsc}, 10)
+	assert.Empty(t, m.query)
+	assert.Equal(t, []string{"cmd/", "pkg/", "README.md", "go.mod"}, rowNames(m))
+	assert.Equal(t, actionCancel, m.handle(key{code: keyEsc}, 10))
+}
+
+func TestTreeModel_Render(t *testing.T) {
+	t.Parallel()
+
+	m := newTreeModel(treeFiles, treeFileStat)
+	m.handle(key{code: keyDown}, 10)
+	m.handle(key{code: keyRune, r: ' '}, 10)
+	m.handle(key{code: keyRight}, 10)
+
+	lines := m.render(40, 6)
+	assert.Len(t, lines, 6)
+	assert.Equal(t, "> ", lines[1])
+	assert.Equal(t, "[ ] ▸ cmd/", lines[2])
+	assert.Equal(t, "\x1b[7m[x] ▾ pkg/\x1b[0m", lines[3], "the cursor row is highlighted")
+	assert.Equal(t, "[x]   ▸ gcat/", lines[4])
+	assert.Equal(t, "3 files selected, 5.2 KiB, ~53 tokens", lines[5])
+
+	m.handle(key{code: keyEnd}, 10)
+	lines = m.render(20, 6)
+	assert.Equal(t, "[x]   ▸ lang/", lines[2], "the tree scrolls to the cursor")
+	assert.Equal(t, "\x1b[7m[ ]   go.mod\x1b[0m", lines[4])
+	assert.Equal(t, "3 files selected, 5…", lines[5])
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, fuzzyMatch("", "anything"))
+	assert.True(t, fuzzyMatch("pgg", "pkg/gcat/gcat.go"))
+	assert.True(t, fuzzyMatch("MAIN", "cmd/gcat/main.go"))
+	assert.False(t, fuzzyMatch("gm", "main.go"))
+}
+
+func TestFormatSize(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "512 B", FormatSize(512))
+	assert.Equal(t, "1.5 KiB", FormatSize(1536))
+	assert.Equal(t, "2.0 MiB", FormatSize(2<<20))
+	assert.Equal(t, "3072.0 GiB", FormatSize(3<<40))
+	assert.Equal(t, "999", formatCount(999))
+	assert.Equal(t, "12.3K", formatCount(12345))
+	assert.Equal(t, "1.5M", formatCount(1500000))
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNotTerminal is returned by TreeSelector when standard input or standard
+// error is not a terminal.
+var ErrNotTerminal = errors.New("the tree selector needs a terminal")
+
+// TreeSelector prompts the user to select files in a full-screen tree view.
+// Directories can be collapsed, expanded and selected as a whole, typing
+// filters the tree with a fuzzy match, and the bottom line shows the number,
+// size and estimated tokens of the selected files. The preselected files
+// start out selected, with their directories expanded. fileStat reports the
+// size and tokens of a file when it is first selected; it may be nil.
+//
+// The tree is drawn on standard error so that standard output can be
+// redirected. TreeSelector returns ErrNotTerminal when it cannot take over
+// the terminal.
+func TreeSelector(files []string, fileStat func(filePath string) FileStat, preselected []string) ([]string, error) {
+	in, out := int(os.Stdin.Fd()), int(os.Stderr.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return nil, ErrNotTerminal
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(in, state)
+
+	// Switch to the alternate screen and hide the cursor while selecting.
+	fmt.Fprint(os.Stderr, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stderr, "\x1b[?25h\x1b[?1049l")
+
+	size := func() (int, int) {
+		width, height, err := term.GetSize(out)
+		if err != nil {
+			return 80, 24
+		}
+		return width, height
+	}
+	m := newTreeModel(files, fileStat)
+	m.preselect(preselected)
+	return runTreeSelector(m, bufio.NewReader(os.Stdin), os.Stderr, size)
+}
+
+// runTreeSelector redraws the selector on out after every key read from in
+// until the selection is confirmed or cancelled.
+func runTreeSelector(m *treeModel, in *bufio.Reader, out io.Writer, size func() (int, int)) ([]string, error) {
+	for {
+		width, height := size()
+		var frame strings.Builder
+		frame.WriteString("\x1b[H")
+		for i, line := range m.render(width, height) {
+			if i > 0 {
+				frame.WriteString("\r\n")
+			}
+			frame.WriteString(line)
+			frame.WriteString("\x1b[K")
+		}
+		frame.WriteString("\x1b[J")
+		if _, err := io.WriteString(out, frame.String()); err != nil {
+			return nil, err
+		}
+
+		k, err := readKey(in)
+		if err != nil {
+			return nil, err
+		}
+		switch m.handle(k, max(1, height-3)) {
+		case actionConfirm:
+			selected := m.selection()
+			if len(selected) == 0 {
+				return nil, fmt.Errorf("no files selected")
+			}
+			return selected, nil
+		case actionCancel:
+			return nil, fmt.Errorf("selection cancelled")
+		}
+	}
+}
+
+// keyCode identifies a key read by readKey.
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyEnter
+	keyEsc
+	keyTab
+	keyBackspace
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyCtrlA
+	keyCtrlC
+	keyCtrlU
+	keyUnknown
+)
+
+// key is a key press. r is the character typed for keyRune.
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// escapeKeys maps the final part of CSI and SS3 escape sequences to keys.
+var escapeKeys = map[string]keyCode{
+	"A":  keyUp,
+	"B":  keyDown,
+	"C":  keyRight,
+	"D":  keyLeft,
+	"H":  keyHome,
+	"F":  keyEnd,
+	"1~": keyHome,
+	"4~": keyEnd,
+	"7~": keyHome,
+	"8~": keyEnd,
+	"5~": keyPageUp,
+	"6~": keyPageDown,
+}
+
+// readKey reads a key press from a terminal in raw mode. An escape byte
+// with nothing buffered after it is the Esc key; otherwise it starts an
+// escape sequence.
+func readKey(in *bufio.Reader) (key, error) {
+	b, err := in.ReadByte()
+	if err != nil {
+		return key{}, err
+	}
+
+	switch b {
+	case '\r', '\n':
+		return key{code: keyEnter}, nil
+	case '\t':
+		return key{code: keyTab}, nil
+	case 0x7f, 0x08:
+		return key{code: keyBackspace}, nil
+	case 0x01:
+		return key{code: keyCtrlA}, nil
+	case 0x03:
+		return key{code: keyCtrlC}, nil
+	case 0x0e:
+		return key{code: keyDown}, nil
+	case 0x10:
+		return key{code: keyUp}, nil
+	case 0x15:
+		return key{code: keyCtrlU}, nil
+	case 0x1b:
+		if in.Buffered() == 0 {
+			return key{code: keyEsc}, nil
+		}
+		return readEscape(in)
+	}
+
+	if b < 0x20 {
+		return key{code: keyUnknown}, nil
+	}
+	if err := in.UnreadByte(); err != nil {
+		return key{}, err
+	}
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return key{}, err
+	}
+	return key{code: keyRune, r: r}, nil
+}
+
+// readEscape reads the rest of an escape sequence after the escape byte.
+func readEscape(in *bufio.Reader) (key, error) {
+	intro, err := in.ReadByte()
+	if err != nil {
+		return key{}, err
+	}
+	if intro != '[' && intro != 'O' {
+		return key{code: keyUnknown}, nil
+	}
+
+	var seq []byte
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return key{}, err
+		}
+		seq = append(seq, b)
+		// Parameters and intermediates are 0x20-0x3f; a final byte ends the
+		// sequence.
+		if b >= 0x40 && b <= 0x7e {
+			break
+		}
+	}
+
+	// Drop modifier parameters such as the ";5" of ctrl+up.
+	s := string(seq)
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		s = s[:i] + s[len(s)-1:]
+	}
+	if s != "" && s[0] == '1' && len(s) == 2 && s[1] != '~' {
+		s = s[1:]
+	}
+	if code, ok := escapeKeys[s]; ok {
+		return key{code: code}, nil
+	}
+	return key{code: keyUnknown}, nil
+}
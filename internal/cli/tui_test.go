@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadKey(t *testing.T) {
+	t.Parallel()
+
+	in := bufio.NewReader(strings.NewReader("a é\r\t\x7f\x01\x03\x15\x1b[A\x1b[B\x1bOC\x1b[D\x1b[1;5A\x1b[5~\x1b[6~\x1b[H\x1b[4~\x1b[3~\x1bx\x02"))
+	want := []key{
+		{code: keyRune, r: 'a'},
+		{code: keyRune, r: ' '},
+		{code: keyRune, r: 'é'},
+		{code: keyEnter},
+		{code: keyTab},
+		{code: keyBackspace},
+		{code: keyCtrlA},
+		{code: keyCtrlC},
+		{code: keyCtrlU},
+		{code: keyUp},
+		{code: keyDown},
+		{code: keyRight},
+		{code: keyLeft},
+		{code: keyUp},
+		{code: keyPageUp},
+		{code: keyPageDown},
+		{code: keyHome},
+		{code: keyEnd},
+		{code: keyUnknown},
+		{code: keyUnknown},
+		{code: keyUnknown},
+	}
+	for _, w := range want {
+		got, err := readKey(in)
+		require.NoError(t, err)
+		assert.Equal(t, w, got)
+	}
+	_, err := readKey(in)
+	assert.ErrorIs(t, err, io.EOF)
+
+	// A lone escape byte is the Esc key.
+	got, err := readKey(bufio.NewReader(strings.NewReader("\x1b")))
+	require.NoError(t, err)
+	assert.Equal(t, key{code: keyEsc}, got)
+}
+
+func TestRunTreeSelector(t *testing.T) {
+	t.Parallel()
+
+	size := func() (int, int) { return 60, 10 }
+
+	tests := []struct {
+		name        string
+		keys        string
+		want        []string
+		expectedErr string
+	}{
+		{
+			name: "select a directory and a file",
+			keys: "\x1b[B \x1b[B\x1b[B \r",
+			want: []string{"go.mod", "pkg/gcat/gcat.go", "pkg/gcat/gcat_test.go", "pkg/lang/lang.go"},
+		},
+		{
+			name: "filter then select all matches",
+			keys: "lang\x01\r",
+			want: []string{"pkg/lang/lang.go"},
+		},
+		{
+			name: "tab selects and moves down",
+			keys: "\t\t\r",
+			want: []string{"cmd/gcat/main.go", "pkg/gcat/gcat.go", "pkg/gcat/gcat_test.go", "pkg/lang/lang.go"},
+		},
+		{
+			name:        "nothing selected",
+			keys:        "\r",
+			expectedErr: "no files selected",
+		},
+		{
+			name:        "ctrl-c cancels",
+			keys:        " \x03",
+			expectedErr: "selection cancelled",
+		},
+		{
+			name:        "input ends",
+			keys:        " ",
+			expectedErr: "EOF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out strings.Builder
+			got, err := runTreeSelector(newTreeModel(treeFiles, nil), bufio.NewReader(strings.NewReader(tt.keys)), &out, size)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Contains(t, out.String(), "\x1b[H")
+		})
+	}
+}
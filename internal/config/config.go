@@ -0,0 +1,340 @@
+// Package config loads gcat settings from YAML configuration files.
+//
+// Settings are read, from lowest to highest precedence, from the user
+// configuration file, the project file in the root of the source and the
+// file named by $GCAT_CONFIG. Command-line flags override all of them.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvVar names the environment variable pointing at an extra configuration
+// file.
+const EnvVar = "GCAT_CONFIG"
+
+// ProjectFiles are the names of the project configuration file, in the order
+// they are looked for in the root of the source. Only the first one found is
+// read.
+var ProjectFiles = []string{".gcat.yaml", ".gcat.yml", ".gcatrc"}
+
+// Settings are the options a configuration file or profile can set. Zero
+// values are unset and leave lower-precedence values in place.
+type Settings struct {
+	// Include and Exclude are doublestar glob patterns selecting files, like
+	// the --include and --exclude flags.
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+	// Languages maps file extensions, such as ".tpl", to language names.
+	Languages map[string]string `yaml:"languages,omitempty"`
+	// Format is the name of the output format.
+	Format string `yaml:"format,omitempty"`
+	// Tokenizer, MaxTokens and OnExceed set the token budget.
+	Tokenizer string `yaml:"tokenizer,omitempty"`
+	MaxTokens int    `yaml:"max-tokens,omitempty"`
+	OnExceed  string `yaml:"on-exceed,omitempty"`
+	// Redact turns the redaction of secrets on or off, like the --redact
+	// and --no-redact flags.
+	Redact *bool `yaml:"redact,omitempty"`
+	// Sensitive are patterns of files that are never written, in addition to
+	// the built-in deny list, unless --allow-sensitive is given.
+	Sensitive []string `yaml:"sensitive,omitempty"`
+}
+
+// Merge overrides s with the values set in other. Glob lists replace each
+// other, except for the sensitive file patterns, which add up so that no
+// file can lift a deny list set by another. Language mappings are merged
+// extension by extension.
+func (s *Settings) Merge(other Settings) {
+	if len(other.Include) > 0 {
+		s.Include = other.Include
+	}
+	if len(other.Exclude) > 0 {
+		s.Exclude = other.Exclude
+	}
+	for ext, name := range other.Languages {
+		if s.Languages == nil {
+			s.Languages = map[string]string{}
+		}
+		s.Languages[ext] = name
+	}
+	if other.Format != "" {
+		s.Format = other.Format
+	}
+	if other.Tokenizer != "" {
+		s.Tokenizer = other.Tokenizer
+	}
+	if other.MaxTokens != 0 {
+		s.MaxTokens = other.MaxTokens
+	}
+	if other.OnExceed != "" {
+		s.OnExceed = other.OnExceed
+	}
+	if other.Redact != nil {
+		s.Redact = other.Redact
+	}
+	for _, pattern := range other.Sensitive {
+		if !slices.Contains(s.Sensitive, pattern) {
+			s.Sensitive = append(s.Sensitive, pattern)
+		}
+	}
+}
+
+// Profile is a named set of settings applied on top of the others with the
+// --profile flag.
+type Profile struct {
+	Settings `yaml:",inline"`
+	// Paths are files selected by the profile in addition to those matching
+	// its globs, such as the files picked in the prompt when the profile was
+	// saved.
+	Paths []string `yaml:"paths,omitempty"`
+}
+
+// Selects reports whether the profile selects files.
+func (p Profile) Selects() bool {
+	return len(p.Include) > 0 || len(p.Exclude) > 0 || len(p.Paths) > 0
+}
+
+// Config is the contents of a configuration file.
+type Config struct {
+	Settings `yaml:",inline"`
+	// Profiles are the named profiles.
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+	// Sources are the configuration files that were read, lowest precedence
+	// first.
+	Sources []string `yaml:"-"`
+}
+
+// Merge overrides c with the settings and profiles of other. Profiles with
+// the same name are merged.
+func (c *Config) Merge(other *Config) {
+	c.Settings.Merge(other.Settings)
+	for name, profile := range other.Profiles {
+		if c.Profiles == nil {
+			c.Profiles = map[string]Profile{}
+		}
+		merged := c.Profiles[name]
+		merged.Settings.Merge(profile.Settings)
+		if len(profile.Paths) > 0 {
+			merged.Paths = profile.Paths
+		}
+		c.Profiles[name] = merged
+	}
+	c.Sources = append(c.Sources, other.Sources...)
+}
+
+// ApplyProfile merges the settings of the named profile into those of c and
+// returns the profile.
+func (c *Config) ApplyProfile(name string) (Profile, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	c.Settings.Merge(profile.Settings)
+	return profile, nil
+}
+
+// ProfileNames returns the names of the profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Marshal returns c as YAML.
+func (c *Config) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Parse parses the YAML configuration data read from name.
+func Parse(name string, data []byte) (*Config, error) {
+	var c Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	c.Languages = normalizeLanguages(c.Languages)
+	for profile, settings := range c.Profiles {
+		settings.Languages = normalizeLanguages(settings.Languages)
+		c.Profiles[profile] = settings
+	}
+	c.Sources = []string{name}
+	return &c, nil
+}
+
+// normalizeLanguages lower-cases extensions and adds their leading dot, so
+// "TPL" and ".tpl" both map files ending in ".tpl".
+func normalizeLanguages(languages map[string]string) map[string]string {
+	if len(languages) == 0 {
+		return nil
+	}
+	normalized := make(map[string]string, len(languages))
+	for ext, name := range languages {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized[ext] = name
+	}
+	return normalized
+}
+
+// UserFile returns the path of the user configuration file,
+// gcat/config.yaml in the user configuration directory.
+func UserFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gcat", "config.yaml"), nil
+}
+
+// ProjectFile returns the path of the project configuration file in dir: the
+// first of ProjectFiles that exists, or .gcat.yaml if none does.
+func ProjectFile(dir string) string {
+	for _, name := range ProjectFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, ProjectFiles[0])
+}
+
+// SaveProfile stores profile under name in the configuration file at path,
+// replacing any profile of that name and creating the file if needed. The
+// rest of the file, including its comments, is kept.
+func SaveProfile(path, name string, profile Profile) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Tag == "!!null" {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: expected a mapping at the top level", path)
+	}
+
+	profiles := mapValue(root, "profiles")
+	if profiles.Kind != yaml.MappingNode {
+		*profiles = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	var value yaml.Node
+	if err := value.Encode(profile); err != nil {
+		return err
+	}
+	*mapValue(profiles, name) = value
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// mapValue returns the value of key in the mapping node m, adding an empty
+// entry if there is none.
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
+
+// Load reads and merges the user configuration file, the project file and
+// the file named by $GCAT_CONFIG. readProject reads a file from root, the
+// root of the source, and must return an error wrapping fs.ErrNotExist for
+// missing files; it may be nil when there is no project. Missing user and
+// project files are skipped, but a missing $GCAT_CONFIG file is an error.
+func Load(root string, readProject func(name string) ([]byte, error)) (*Config, error) {
+	merged := &Config{}
+
+	if path, err := UserFile(); err == nil {
+		c, err := loadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if c != nil {
+			merged.Merge(c)
+		}
+	}
+
+	if readProject != nil {
+		for _, name := range ProjectFiles {
+			data, err := readProject(name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", name, err)
+			}
+			c, err := Parse(strings.TrimSuffix(root, "/")+"/"+name, data)
+			if err != nil {
+				return nil, err
+			}
+			merged.Merge(c)
+			break
+		}
+	}
+
+	if path := os.Getenv(EnvVar); path != "" {
+		c, err := loadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("$%s: %w", EnvVar, err)
+		}
+		merged.Merge(c)
+	}
+
+	return merged, nil
+}
+
+func loadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data)
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	c, err := Parse("test.yaml", []byte(`
+include: ["**/*.go"]
+exclude: ["**/*_test.go"]
+languages:
+  tpl: Go Template
+  .PROTO: Protocol Buffer
+format: markdown
+max-tokens: 1000
+redact: false
+profiles:
+  docs:
+    include: ["docs/**"]
+    languages:
+      MDX: Markdown
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"**/*.go"}, c.Include)
+	assert.Equal(t, []string{"**/*_test.go"}, c.Exclude)
+	assert.Equal(t, map[string]string{".tpl": "Go Template", ".proto": "Protocol Buffer"}, c.Languages)
+	assert.Equal(t, "markdown", c.Format)
+	assert.Equal(t, 1000, c.MaxTokens)
+	if assert.NotNil(t, c.Redact) {
+		assert.False(t, *c.Redact)
+	}
+	assert.Equal(t, map[string]string{".mdx": "Markdown"}, c.Profiles["docs"].Languages)
+	assert.Equal(t, []string{"test.yaml"}, c.Sources)
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse("bad.yaml", []byte("formatt: json\n"))
+	assert.ErrorContains(t, err, "parsing bad.yaml")
+
+	c, err := Parse("empty.yaml", nil)
+	require.NoError(t, err)
+	assert.Empty(t, c.Settings)
+}
+
+func TestConfig_ApplyProfile(t *testing.T) {
+	t.Parallel()
+
+	c := &Config{
+		Settings: Settings{Include: []string{"**/*.go"}, Format: "xml", MaxTokens: 500},
+		Profiles: map[string]Profile{
+			"review": {Settings: Settings{Include: []string{"api/**"}, Format: "markdown"}, Paths: []string{"go.mod"}},
+			"all":    {},
+		},
+	}
+
+	profile, err := c.ApplyProfile("review")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go.mod"}, profile.Paths)
+	assert.True(t, profile.Selects())
+	assert.False(t, c.Profiles["all"].Selects())
+	assert.Equal(t, []string{"api/**"}, c.Include)
+	assert.Equal(t, "markdown", c.Format)
+	assert.Equal(t, 500, c.MaxTokens)
+
+	_, err = c.ApplyProfile("missing")
+	assert.EqualError(t, err, `unknown profile "missing" (available: all, review)`)
+}
+
+func TestLoad(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+
+	userFile, err := UserFile()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(userFile), 0o755))
+	require.NoError(t, os.WriteFile(userFile, []byte(`
+format: xml
+tokenizer: cl100k_base
+sensitive: ["*.secret"]
+languages:
+  .tpl: Smarty
+profiles:
+  review:
+    include: ["**/*.go"]
+`), 0o644))
+
+	project := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".gcatrc"), []byte(`
+format: markdown
+exclude: ["vendor/**"]
+sensitive: ["deploy/*.yaml", "*.secret"]
+languages:
+  .tpl: Go Template
+profiles:
+  review:
+    exclude: ["**/*_test.go"]
+`), 0o644))
+	readProject := func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(project, name))
+	}
+
+	envFile := filepath.Join(t.TempDir(), "env.yaml")
+	require.NoError(t, os.WriteFile(envFile, []byte("max-tokens: 2000\n"), 0o644))
+	t.Setenv(EnvVar, envFile)
+
+	c, err := Load(project, readProject)
+	require.NoError(t, err)
+
+	assert.Equal(t, "markdown", c.Format, "project overrides user")
+	assert.Equal(t, "cl100k_base", c.Tokenizer)
+	assert.Equal(t, 2000, c.MaxTokens)
+	assert.Equal(t, []string{"vendor/**"}, c.Exclude)
+	assert.Equal(t, []string{"*.secret", "deploy/*.yaml"}, c.Sensitive, "sensitive patterns add up")
+	assert.Equal(t, map[string]string{".tpl": "Go Template"}, c.Languages)
+	assert.Equal(t, Profile{Settings: Settings{Include: []string{"**/*.go"}, Exclude: []string{"**/*_test.go"}}}, c.Profiles["review"])
+	assert.Equal(t, []string{userFile, project + "/.gcatrc", envFile}, c.Sources)
+
+	t.Setenv(EnvVar, filepath.Join(home, "missing.yaml"))
+	_, err = Load(project, readProject)
+	assert.ErrorContains(t, err, "$GCAT_CONFIG")
+
+	t.Setenv(EnvVar, "")
+	c, err = Load("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{userFile}, c.Sources)
+}
+
+func TestConfig_Marshal(t *testing.T) {
+	t.Parallel()
+
+	c := &Config{
+		Settings: Settings{Format: "json", Languages: map[string]string{".tpl": "Go Template"}},
+		Profiles: map[string]Profile{"docs": {Settings: Settings{Include: []string{"docs/**"}}}},
+		Sources:  []string{"ignored.yaml"},
+	}
+	data, err := c.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, `languages:
+  .tpl: Go Template
+format: json
+profiles:
+  docs:
+    include:
+      - docs/**
+`, string(data))
+}
+
+func TestSaveProfile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := ProjectFile(dir)
+	assert.Equal(t, filepath.Join(dir, ".gcat.yaml"), path)
+
+	require.NoError(t, SaveProfile(path, "api", Profile{Paths: []string{"api/handlers.go", "schema.sql"}}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `profiles:
+  api:
+    paths:
+      - api/handlers.go
+      - schema.sql
+`, string(data))
+
+	rc := filepath.Join(dir, ".gcatrc")
+	require.NoError(t, os.WriteFile(rc, []byte(`# Team defaults.
+format: markdown # for the wiki
+profiles:
+  api:
+    include: ["api/**"]
+  docs:
+    include: ["docs/**"]
+`), 0o644))
+	require.NoError(t, os.Remove(path))
+	assert.Equal(t, rc, ProjectFile(dir))
+
+	require.NoError(t, SaveProfile(rc, "api", Profile{Settings: Settings{Include: []string{"**/*.go"}}}))
+	data, err = os.ReadFile(rc)
+	require.NoError(t, err)
+	assert.Equal(t, `# Team defaults.
+format: markdown # for the wiki
+profiles:
+  api:
+    include:
+      - '**/*.go'
+  docs:
+    include: ["docs/**"]
+`, string(data))
+
+	c, err := Parse(rc, data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"**/*.go"}, c.Profiles["api"].Include)
+	assert.Equal(t, []string{"docs/**"}, c.Profiles["docs"].Include)
+}
+
+func TestSaveProfileInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".gcat.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("- a\n- b\n"), 0o644))
+
+	assert.ErrorContains(t, SaveProfile(path, "x", Profile{Paths: []string{"a"}}), "expected a mapping")
+}
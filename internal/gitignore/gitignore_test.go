@@ -0,0 +1,344 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// check is a single path expected to be ignored or not.
+type check struct {
+	path    string
+	isDir   bool
+	ignored bool
+}
+
+// TestMatcher_Conformance covers the rules and examples from gitignore(5).
+func TestMatcher_Conformance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		patterns string
+		checks   []check
+	}{
+		{
+			name:     "blank lines and comments match nothing",
+			patterns: "\n# comment\n   \n",
+			checks: []check{
+				{path: "# comment"},
+				{path: "comment"},
+			},
+		},
+		{
+			name:     "escaped hash and exclamation mark are literal",
+			patterns: "\\#file\n\\!important\n",
+			checks: []check{
+				{path: "#file", ignored: true},
+				{path: "!important", ignored: true},
+				{path: "important"},
+			},
+		},
+		{
+			name:     "trailing spaces are ignored unless escaped",
+			patterns: "foo   \nbar\\ \n",
+			checks: []check{
+				{path: "foo", ignored: true},
+				{path: "foo   "},
+				{path: "bar ", ignored: true},
+				{path: "bar"},
+			},
+		},
+		{
+			name:     "pattern without a slash matches at any depth",
+			patterns: "hello.*\n",
+			checks: []check{
+				{path: "hello.txt", ignored: true},
+				{path: "a/b/hello.c", ignored: true},
+				{path: "a/hello", isDir: true},
+			},
+		},
+		{
+			name:     "leading slash anchors to the .gitignore directory",
+			patterns: "/hello.*\n",
+			checks: []check{
+				{path: "hello.txt", ignored: true},
+				{path: "a/hello.txt"},
+			},
+		},
+		{
+			name:     "slash in the middle anchors the pattern",
+			patterns: "doc/frotz/\n",
+			checks: []check{
+				{path: "doc/frotz", isDir: true, ignored: true},
+				{path: "doc/frotz/file.txt", ignored: true},
+				{path: "a/doc/frotz", isDir: true},
+			},
+		},
+		{
+			name:     "trailing slash matches directories only",
+			patterns: "frotz/\n",
+			checks: []check{
+				{path: "frotz", isDir: true, ignored: true},
+				{path: "a/frotz", isDir: true, ignored: true},
+				{path: "a/frotz/file", ignored: true},
+				{path: "frotz"},
+			},
+		},
+		{
+			name:     "star does not match a slash",
+			patterns: "doc/*.txt\n",
+			checks: []check{
+				{path: "doc/notes.txt", ignored: true},
+				{path: "doc/server/arch.txt"},
+			},
+		},
+		{
+			name:     "negation re-includes a file",
+			patterns: "*.html\n!foo.html\n",
+			checks: []check{
+				{path: "bar.html", ignored: true},
+				{path: "foo.html"},
+				{path: "sub/foo.html"},
+			},
+		},
+		{
+			name:     "last matching pattern wins",
+			patterns: "!foo.html\n*.html\n",
+			checks: []check{
+				{path: "foo.html", ignored: true},
+			},
+		},
+		{
+			name:     "exclude everything except foo/bar",
+			patterns: "/*\n!/foo\n/foo/*\n!/foo/bar\n",
+			checks: []check{
+				{path: "baz", isDir: true, ignored: true},
+				{path: "foo", isDir: true},
+				{path: "foo/baz", ignored: true},
+				{path: "foo/bar", isDir: true},
+				{path: "foo/bar/file"},
+			},
+		},
+		{
+			name:     "files cannot be re-included inside an excluded directory",
+			patterns: "build/\n!build/keep.txt\n",
+			checks: []check{
+				{path: "build/keep.txt", ignored: true},
+				{path: "build/other.txt", ignored: true},
+			},
+		},
+		{
+			name:     "leading double star matches in all directories",
+			patterns: "**/foo\n**/baz/bar\n",
+			checks: []check{
+				{path: "foo", ignored: true},
+				{path: "a/b/foo", ignored: true},
+				{path: "baz/bar", ignored: true},
+				{path: "x/baz/bar", ignored: true},
+				{path: "bar"},
+			},
+		},
+		{
+			name:     "trailing double star matches everything inside",
+			patterns: "abc/**\n",
+			checks: []check{
+				{path: "abc/file", ignored: true},
+				{path: "abc/x/y/file", ignored: true},
+				{path: "abc", isDir: true},
+				{path: "x/abc/file"},
+			},
+		},
+		{
+			name:     "double star in the middle matches zero or more directories",
+			patterns: "a/**/b\n",
+			checks: []check{
+				{path: "a/b", ignored: true},
+				{path: "a/x/b", ignored: true},
+				{path: "a/x/y/b", ignored: true},
+				{path: "a/x/c"},
+			},
+		},
+		{
+			name:     "other consecutive stars are regular stars",
+			patterns: "foo**bar\n",
+			checks: []check{
+				{path: "foobar", ignored: true},
+				{path: "foo-bar", ignored: true},
+				{path: "foo/bar"},
+			},
+		},
+		{
+			name:     "question mark and character classes",
+			patterns: "*.[oa]\nfile?.txt\n[!a]bc\nv[[:digit:]]\n",
+			checks: []check{
+				{path: "main.o", ignored: true},
+				{path: "lib.a", ignored: true},
+				{path: "main.c"},
+				{path: "file1.txt", ignored: true},
+				{path: "file10.txt"},
+				{path: "xbc", ignored: true},
+				{path: "abc"},
+				{path: "v1", ignored: true},
+				{path: "vx"},
+			},
+		},
+		{
+			name:     "character ranges",
+			patterns: "log-[0-9][a-c]\n",
+			checks: []check{
+				{path: "log-1b", ignored: true},
+				{path: "log-1d"},
+			},
+		},
+		{
+			name:     "escaped glob characters are literal",
+			patterns: "\\*.txt\nfile\\?\n",
+			checks: []check{
+				{path: "*.txt", ignored: true},
+				{path: "a.txt"},
+				{path: "file?", ignored: true},
+				{path: "file1"},
+			},
+		},
+		{
+			name:     "simple patterns",
+			patterns: "*.log\ntemp/\nvendor/\n",
+			checks: []check{
+				{path: "error.log", ignored: true},
+				{path: "temp/file.txt", ignored: true},
+				{path: "vendor/package/file.go", ignored: true},
+				{path: "src/main.go"},
+				{path: "doc/readme.md"},
+				{path: "tempdir/file.txt"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			patterns, err := Parse(strings.NewReader(tt.patterns), "")
+			require.NoError(t, err)
+			matcher := NewMatcher(patterns)
+
+			for _, c := range tt.checks {
+				assert.Equal(t, c.ignored, matcher.Match(c.path, c.isDir), "Match(%q, isDir=%v)", c.path, c.isDir)
+			}
+		})
+	}
+}
+
+func TestStack(t *testing.T) {
+	t.Parallel()
+
+	base, err := Parse(strings.NewReader("*.tmp\n"), "")
+	require.NoError(t, err)
+	stack := NewStack(base)
+
+	root, err := Parse(strings.NewReader("*.log\n/build\n"), "")
+	require.NoError(t, err)
+	stack.Push("", root)
+
+	sub, err := Parse(strings.NewReader("!keep.log\n/only\n*.txt\n"), "sub")
+	require.NoError(t, err)
+	stack.Push("sub", sub)
+
+	checks := []check{
+		{path: "a.tmp", ignored: true},
+		{path: "sub/deep/a.tmp", ignored: true},
+		{path: "debug.log", ignored: true},
+		{path: "sub/debug.log", ignored: true},
+		{path: "sub/keep.log"},
+		{path: "sub/deep/keep.log"},
+		{path: "keep.log", ignored: true},
+		{path: "build", isDir: true, ignored: true},
+		{path: "sub/build", isDir: true},
+		{path: "sub/only", ignored: true},
+		{path: "sub/deep/only"},
+		{path: "only"},
+		{path: "notes.txt"},
+		{path: "sub/notes.txt", ignored: true},
+		{path: "sub/deep/notes.txt", ignored: true},
+		{path: "subway/notes.txt"},
+	}
+	for _, c := range checks {
+		assert.Equal(t, c.ignored, stack.Match(c.path, c.isDir), "Match(%q, isDir=%v)", c.path, c.isDir)
+	}
+}
+
+func TestStack_PushFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("# comment\n*.log\r\n"), 0o644))
+
+	stack := NewStack(nil)
+	require.NoError(t, stack.PushFile("", dir))
+	require.NoError(t, stack.PushFile("missing", filepath.Join(dir, "missing")))
+
+	assert.True(t, stack.Match("debug.log", false))
+	assert.True(t, stack.Match("missing/debug.log", false))
+	assert.False(t, stack.Match("main.go", false))
+}
+
+func TestWildmatch(t *testing.T) {
+	t.Parallel()
+
+	// Cases adapted from git's t/t3070-wildmatch.sh for single path segments.
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "foo", name: "foo", want: true},
+		{pattern: "bar", name: "foo", want: false},
+		{pattern: "", name: "", want: true},
+		{pattern: "???", name: "foo", want: true},
+		{pattern: "??", name: "foo", want: false},
+		{pattern: "*", name: "foo", want: true},
+		{pattern: "f*", name: "foo", want: true},
+		{pattern: "*f", name: "foo", want: false},
+		{pattern: "*foo*", name: "foo", want: true},
+		{pattern: "*ob*a*r*", name: "foobar", want: true},
+		{pattern: "*ab", name: "aaaaaaabababab", want: true},
+		{pattern: "foo\\*", name: "foo*", want: true},
+		{pattern: "foo\\*bar", name: "foobar", want: false},
+		{pattern: "f\\\\oo", name: "f\\oo", want: true},
+		{pattern: "*[al]?", name: "ball", want: true},
+		{pattern: "[ten]", name: "ten", want: false},
+		{pattern: "**[!te]", name: "ten", want: true},
+		{pattern: "**[!ten]", name: "ten", want: false},
+		{pattern: "t[a-g]n", name: "ten", want: true},
+		{pattern: "t[!a-g]n", name: "ten", want: false},
+		{pattern: "t[!a-g]n", name: "ton", want: true},
+		{pattern: "t[^a-g]n", name: "ton", want: true},
+		{pattern: "a[]]b", name: "a]b", want: true},
+		{pattern: "a[]-]b", name: "a-b", want: true},
+		{pattern: "a[]a-]b", name: "aab", want: true},
+		{pattern: "]", name: "]", want: true},
+		{pattern: "[", name: "[", want: true},
+		{pattern: "[!", name: "[!", want: true},
+		{pattern: "a[", name: "a[", want: true},
+		{pattern: "[\\]]", name: "]", want: true},
+		{pattern: "[\\-_]", name: "-", want: true},
+		{pattern: "[[:alpha:]][[:digit:]][[:upper:]]", name: "a1B", want: true},
+		{pattern: "[[:digit:][:upper:][:space:]]", name: "a", want: false},
+		{pattern: "[[:xdigit:]]", name: "f", want: true},
+		{pattern: "*.txt", name: "日本.txt", want: true},
+		{pattern: "??.txt", name: "日本.txt", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, wildmatch(tt.pattern, tt.name))
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package gitignore
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Matcher decides whether paths are ignored by an ordered list of patterns.
+type Matcher struct {
+	patterns []Pattern
+}
+
+// NewMatcher returns a Matcher for patterns in ascending order of priority:
+// when several patterns match a path, the last one decides.
+func NewMatcher(patterns []Pattern) *Matcher {
+	return &Matcher{patterns: patterns}
+}
+
+// Match reports whether path is ignored. A path inside an ignored directory is
+// always ignored, since git cannot re-include files below an excluded
+// directory.
+func (m *Matcher) Match(path string, isDir bool) bool {
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		if ignored(m.patterns, strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return ignored(m.patterns, path, isDir)
+}
+
+// ignored applies patterns to path alone, ignoring its parent directories.
+func ignored(patterns []Pattern, path string, isDir bool) bool {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if patterns[i].Match(path, isDir) {
+			return !patterns[i].negate
+		}
+	}
+	return false
+}
+
+// Stack tracks the rules in effect for each directory of a tree walk. Every
+// directory sees its own .gitignore patterns on top of those of its parents,
+// which in turn sit on top of the base patterns.
+type Stack struct {
+	dirs map[string][]Pattern
+}
+
+// NewStack returns a Stack whose root directory uses base, such as the
+// patterns from .git/info/exclude and the global excludes file.
+func NewStack(base []Pattern) *Stack {
+	return &Stack{dirs: map[string][]Pattern{"": base}}
+}
+
+// Push records the patterns read from the .gitignore file of dir ("" for the
+// root). Each directory is pushed once, after its parent.
+func (s *Stack) Push(dir string, patterns []Pattern) {
+	if len(patterns) == 0 {
+		// The directory inherits its parent's rules as they are.
+		return
+	}
+	parent := s.dirs[""]
+	if dir != "" {
+		parent = s.rules(path.Dir(dir))
+	}
+	rules := make([]Pattern, 0, len(parent)+len(patterns))
+	s.dirs[dir] = append(append(rules, parent...), patterns...)
+}
+
+// PushFile reads the .gitignore file of dir from disk, where dirPath is the
+// directory's location on disk, and pushes its patterns. A missing file has
+// no patterns.
+func (s *Stack) PushFile(dir, dirPath string) error {
+	file, err := os.Open(filepath.Join(dirPath, ".gitignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	patterns, err := Parse(file, dir)
+	if err != nil {
+		return err
+	}
+	s.Push(dir, patterns)
+	return nil
+}
+
+// Match reports whether path is ignored by the rules of the directories that
+// contain it, including when one of those directories is itself ignored.
+func (s *Stack) Match(p string, isDir bool) bool {
+	parts := strings.Split(p, "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		if ignored(s.rules(path.Dir(dir)), dir, true) {
+			return true
+		}
+	}
+	return ignored(s.rules(path.Dir(p)), p, isDir)
+}
+
+// rules returns the patterns in effect inside dir: those of the closest
+// pushed directory at or above it.
+func (s *Stack) rules(dir string) []Pattern {
+	for {
+		if dir == "." {
+			dir = ""
+		}
+		if rules, ok := s.dirs[dir]; ok {
+			return rules
+		}
+		if dir == "" {
+			return nil
+		}
+		dir = path.Dir(dir)
+	}
+}
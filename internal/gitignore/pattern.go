@@ -0,0 +1,135 @@
+// Package gitignore implements git's ignore rules as documented in
+// gitignore(5).
+//
+// Paths are always slash-separated and relative to the root of the tree being
+// matched. Patterns remember the directory of the file they were read from, so
+// rules from nested .gitignore files only apply below that directory.
+package gitignore
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Pattern is a single rule from a .gitignore file.
+type Pattern struct {
+	base     []string
+	segments []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ParsePattern parses one line of a .gitignore file located in the directory
+// base ("" for the root). It returns false for blank lines and comments.
+func ParsePattern(line, base string) (Pattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Pattern{}, false
+	}
+
+	var p Pattern
+	if base != "" {
+		p.base = strings.Split(strings.Trim(base, "/"), "/")
+	}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return Pattern{}, false
+	}
+
+	// A slash anywhere but at the end anchors the pattern to base; without one
+	// the pattern matches a name at any depth.
+	p.anchored = strings.Contains(line, "/")
+	p.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	return p, true
+}
+
+// Parse reads the patterns of a .gitignore file located in the directory base.
+func Parse(r io.Reader, base string) ([]Pattern, error) {
+	var patterns []Pattern
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if p, ok := ParsePattern(scanner.Text(), base); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// Negated reports whether the pattern re-includes paths ("!pattern").
+func (p Pattern) Negated() bool { return p.negate }
+
+// Match reports whether the pattern matches path. It does not consider
+// whether the pattern is negated, nor the parent directories of path; see
+// Matcher for the full rules.
+func (p Pattern) Match(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) <= len(p.base) {
+		return false
+	}
+	for i, dir := range p.base {
+		if parts[i] != dir {
+			return false
+		}
+	}
+	parts = parts[len(p.base):]
+
+	if !p.anchored {
+		return wildmatch(p.segments[0], parts[len(parts)-1])
+	}
+	return matchSegments(p.segments, parts)
+}
+
+// matchSegments matches path segments against pattern segments, where a "**"
+// segment matches any number of path segments.
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			// A trailing "/**" matches everything inside, but not the
+			// directory itself.
+			return len(path) > 0
+		}
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 || !wildmatch(pattern[0], path[0]) {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
+
+// trimTrailingSpaces removes trailing spaces that are not escaped with a
+// backslash.
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") {
+		trimmed := line[:len(line)-1]
+		backslashes := len(trimmed) - len(strings.TrimRight(trimmed, `\`))
+		if backslashes%2 == 1 {
+			break
+		}
+		line = trimmed
+	}
+	return line
+}
@@ -0,0 +1,159 @@
+package gitignore
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wildmatch matches a single path segment against a glob. "*" matches any run
+// of characters, "?" any single character, "[...]" a character class and a
+// backslash escapes the character that follows it.
+func wildmatch(pattern, name string) bool {
+	px, nx := 0, 0
+	starPx, starNx := -1, -1
+	for px < len(pattern) || nx < len(name) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				starPx, starNx = px, nx
+				px++
+				continue
+			case '?':
+				if nx < len(name) {
+					_, size := utf8.DecodeRuneInString(name[nx:])
+					px++
+					nx += size
+					continue
+				}
+			case '[':
+				if nx < len(name) {
+					r, size := utf8.DecodeRuneInString(name[nx:])
+					if matched, width := matchClass(pattern[px:], r); width > 0 {
+						if matched {
+							px += width
+							nx += size
+							continue
+						}
+						break
+					}
+				}
+				// An unterminated class is a literal "[".
+				if nx < len(name) && name[nx] == '[' {
+					px++
+					nx++
+					continue
+				}
+			case '\\':
+				if px+1 < len(pattern) {
+					if nx < len(name) && name[nx] == pattern[px+1] {
+						px += 2
+						nx++
+						continue
+					}
+					break
+				}
+				// A trailing backslash matches itself.
+				if nx < len(name) && name[nx] == '\\' {
+					px++
+					nx++
+					continue
+				}
+			default:
+				if nx < len(name) && name[nx] == c {
+					px++
+					nx++
+					continue
+				}
+			}
+		}
+		// Mismatch: let the last "*" consume one more character and retry.
+		if starPx >= 0 && starNx < len(name) {
+			_, size := utf8.DecodeRuneInString(name[starNx:])
+			starNx += size
+			px, nx = starPx+1, starNx
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// posixClasses are the character classes supported inside brackets, such as
+// "[[:digit:]]".
+var posixClasses = map[string]func(rune) bool{
+	"alnum":  func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) },
+	"alpha":  unicode.IsLetter,
+	"blank":  func(r rune) bool { return r == ' ' || r == '\t' },
+	"cntrl":  unicode.IsControl,
+	"digit":  unicode.IsDigit,
+	"graph":  func(r rune) bool { return unicode.IsGraphic(r) && !unicode.IsSpace(r) },
+	"lower":  unicode.IsLower,
+	"print":  unicode.IsPrint,
+	"punct":  unicode.IsPunct,
+	"space":  unicode.IsSpace,
+	"upper":  unicode.IsUpper,
+	"xdigit": func(r rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", r) },
+}
+
+// matchClass matches r against the bracket expression at the start of
+// pattern. It returns the width of the expression, or 0 if it is not
+// terminated by "]".
+func matchClass(pattern string, r rune) (matched bool, width int) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+
+	first := true
+	for i < len(pattern) {
+		if pattern[i] == ']' && !first {
+			return matched != negate, i + 1
+		}
+		first = false
+
+		if strings.HasPrefix(pattern[i:], "[:") {
+			if end := strings.Index(pattern[i+2:], ":]"); end >= 0 {
+				if class, ok := posixClasses[pattern[i+2:i+2+end]]; ok {
+					matched = matched || class(r)
+					i += end + 4
+					continue
+				}
+			}
+		}
+
+		lo, size := classChar(pattern[i:])
+		if size == 0 {
+			return false, 0
+		}
+		i += size
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			var hiSize int
+			hi, hiSize = classChar(pattern[i+1:])
+			if hiSize == 0 {
+				return false, 0
+			}
+			i += 1 + hiSize
+		}
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+	return false, 0
+}
+
+// classChar decodes one, possibly escaped, character of a bracket expression.
+func classChar(s string) (rune, int) {
+	if s[0] == '\\' {
+		if len(s) < 2 {
+			return 0, 0
+		}
+		r, size := utf8.DecodeRuneInString(s[1:])
+		return r, size + 1
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return r, size
+}
@@ -0,0 +1,173 @@
+package gcat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// Environment variables read by DefaultAuth.
+const (
+	// GitTokenEnv holds a token used for HTTPS remotes on any host.
+	GitTokenEnv = "GCAT_GIT_TOKEN"
+	// GitUsernameEnv overrides the username sent with GitTokenEnv.
+	GitUsernameEnv = "GCAT_GIT_USERNAME"
+	// GitHubTokenEnv holds a token used for HTTPS remotes on github.com only.
+	GitHubTokenEnv = "GITHUB_TOKEN"
+	// SSHKeyEnv points at the private key used for SSH remotes.
+	SSHKeyEnv = "GCAT_SSH_KEY"
+	// SSHKeyPassphraseEnv holds the passphrase of SSHKeyEnv.
+	SSHKeyPassphraseEnv = "GCAT_SSH_KEY_PASSPHRASE"
+)
+
+// scpLikeURL matches the "user@host:path" form of SSH remotes.
+var scpLikeURL = regexp.MustCompile(`^[^@/\s]+@[^:/\s]+:`)
+
+// defaultSSHKeys are tried in order when no SSH agent or GCAT_SSH_KEY is
+// available.
+var defaultSSHKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// IsRemoteURL reports whether pathOrURL refers to a Git remote rather than a
+// local folder. HTTP(S), ssh:// and git:// URLs as well as the scp-like
+// "git@host:org/repo.git" form are treated as remotes.
+func IsRemoteURL(pathOrURL string) bool {
+	for _, prefix := range []string{"http://", "https://", "ssh://", "git://"} {
+		if strings.HasPrefix(pathOrURL, prefix) {
+			return true
+		}
+	}
+	return scpLikeURL.MatchString(pathOrURL)
+}
+
+// DefaultAuth returns the credentials to clone repoURL with, or nil if none
+// are configured.
+//
+// SSH remotes use the running ssh-agent when SSH_AUTH_SOCK is set, and
+// otherwise the key in GCAT_SSH_KEY or the first of ~/.ssh/id_ed25519,
+// id_ecdsa and id_rsa that exists. HTTPS remotes use GCAT_GIT_TOKEN, then
+// GITHUB_TOKEN for github.com, then whatever the git credential helper
+// returns.
+func DefaultAuth(repoURL string) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch endpoint.Protocol {
+	case "ssh":
+		return defaultSSHAuth(endpoint)
+	case "http", "https":
+		return defaultHTTPAuth(endpoint)
+	}
+	return nil, nil
+}
+
+func defaultSSHAuth(endpoint *transport.Endpoint) (transport.AuthMethod, error) {
+	user := endpoint.User
+	if user == "" {
+		user = "git"
+	}
+
+	keyPath := os.Getenv(SSHKeyEnv)
+	if keyPath == "" && os.Getenv("SSH_AUTH_SOCK") != "" {
+		return ssh.NewSSHAgentAuth(user)
+	}
+	if keyPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range defaultSSHKeys {
+			candidate := filepath.Join(home, ".ssh", name)
+			if _, err := os.Stat(candidate); err == nil {
+				keyPath = candidate
+				break
+			}
+		}
+	}
+	if keyPath == "" {
+		return nil, fmt.Errorf("no SSH credentials found: start ssh-agent or set %s", SSHKeyEnv)
+	}
+
+	auth, err := ssh.NewPublicKeysFromFile(user, keyPath, os.Getenv(SSHKeyPassphraseEnv))
+	if err != nil {
+		return nil, fmt.Errorf("loading SSH key %s: %w", keyPath, err)
+	}
+	return auth, nil
+}
+
+func defaultHTTPAuth(endpoint *transport.Endpoint) (transport.AuthMethod, error) {
+	if endpoint.User != "" && endpoint.Password != "" {
+		// go-git already uses credentials embedded in the URL.
+		return nil, nil
+	}
+
+	if token := os.Getenv(GitTokenEnv); token != "" {
+		username := os.Getenv(GitUsernameEnv)
+		if username == "" {
+			username = "x-access-token"
+		}
+		return &http.BasicAuth{Username: username, Password: token}, nil
+	}
+	if token := os.Getenv(GitHubTokenEnv); token != "" && strings.EqualFold(endpoint.Host, "github.com") {
+		return &http.BasicAuth{Username: "x-access-token", Password: token}, nil
+	}
+
+	username, password := credentialFill(endpoint)
+	if password == "" {
+		return nil, nil
+	}
+	return &http.BasicAuth{Username: username, Password: password}, nil
+}
+
+// runCredentialHelper runs "git credential fill" with input on stdin. Tests
+// override it to avoid depending on the local git configuration.
+var runCredentialHelper = func(input string) (string, error) {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// credentialFill asks the git credential helper for the username and password
+// of endpoint. Any failure, including git not being installed, is treated as
+// having no credentials.
+func credentialFill(endpoint *transport.Endpoint) (username, password string) {
+	host := endpoint.Host
+	if endpoint.Port != 0 {
+		host = fmt.Sprintf("%s:%d", host, endpoint.Port)
+	}
+	input := fmt.Sprintf("protocol=%s\nhost=%s\npath=%s\n", endpoint.Protocol, host, strings.TrimPrefix(endpoint.Path, "/"))
+	if endpoint.User != "" {
+		input += fmt.Sprintf("username=%s\n", endpoint.User)
+	}
+
+	out, err := runCredentialHelper(input + "\n")
+	if err != nil {
+		return "", ""
+	}
+	scanner := bufio.NewScanner(bytes.NewBufferString(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "username":
+			username = value
+		case "password":
+			password = value
+		}
+	}
+	return username, password
+}
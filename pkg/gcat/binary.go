@@ -0,0 +1,225 @@
+package gcat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// BinaryPolicy decides how binary files are listed and rendered.
+type BinaryPolicy string
+
+// Binary policies accepted by WithBinaryPolicy and ParseBinaryPolicy.
+const (
+	// BinarySkip hides binary files from GetFiles and leaves them out of
+	// ConcatFiles and ConcatTo. It is the default.
+	BinarySkip BinaryPolicy = "skip"
+	// BinaryPlaceholder lists binary files and renders a one-line note with
+	// their size in place of their contents.
+	BinaryPlaceholder BinaryPolicy = "placeholder"
+	// BinaryBase64 lists binary files and renders their contents as base64.
+	BinaryBase64 BinaryPolicy = "base64"
+	// BinaryHexdump lists binary files and renders their contents as a
+	// canonical hex dump, like "hexdump -C".
+	BinaryHexdump BinaryPolicy = "hexdump"
+)
+
+var binaryPolicies = []BinaryPolicy{BinarySkip, BinaryPlaceholder, BinaryBase64, BinaryHexdump}
+
+// ParseBinaryPolicy returns the binary policy with the given name.
+func ParseBinaryPolicy(name string) (BinaryPolicy, error) {
+	for _, policy := range binaryPolicies {
+		if strings.EqualFold(name, string(policy)) {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown binary policy %q (available: %s)", name, strings.Join(BinaryPolicyNames(), ", "))
+}
+
+// BinaryPolicyNames returns the names of the binary policies.
+func BinaryPolicyNames() []string {
+	names := make([]string, len(binaryPolicies))
+	for i, policy := range binaryPolicies {
+		names[i] = string(policy)
+	}
+	return names
+}
+
+// sniffLen is how much of a file is inspected to tell binary from text, the
+// same amount git looks at.
+const sniffLen = 8000
+
+// binarySignatures are magic numbers of common binary formats that can start
+// with a run of printable bytes.
+var binarySignatures = [][]byte{
+	[]byte("\x89PNG\r\n\x1a\n"),
+	[]byte("\xff\xd8\xff"), // JPEG
+	[]byte("GIF87a"),
+	[]byte("GIF89a"),
+	[]byte("%PDF-"),
+	[]byte("PK\x03\x04"), // ZIP, JAR, DOCX, ...
+	[]byte("\x1f\x8b"),   // gzip
+	[]byte("7z\xbc\xaf\x27\x1c"),
+	[]byte("\x7fELF"),
+	[]byte("\xcf\xfa\xed\xfe"), // Mach-O, 64-bit
+	[]byte("\xce\xfa\xed\xfe"), // Mach-O, 32-bit
+	[]byte("\xca\xfe\xba\xbe"), // Mach-O universal binary, Java class
+	[]byte("\x00asm"),          // WebAssembly
+	[]byte("SQLite format 3\x00"),
+}
+
+// IsBinary reports whether data, the start of a file, looks like binary
+// content: it has a known binary signature, contains a NUL byte or is not
+// valid UTF-8. Only the first 8000 bytes are inspected.
+func IsBinary(data []byte) bool {
+	truncated := len(data) >= sniffLen
+	if truncated {
+		data = data[:sniffLen]
+	}
+	for _, signature := range binarySignatures {
+		if bytes.HasPrefix(data, signature) {
+			return true
+		}
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			// A multi-byte character cut off by the end of the sample is
+			// not an encoding error.
+			return !truncated || utf8.FullRune(data)
+		}
+		data = data[size:]
+	}
+	return false
+}
+
+// sniff reads up to the first 8000 bytes of r, enough to tell whether it is
+// binary and to detect its language. The returned reader yields the full
+// contents, including the bytes in head.
+func sniff(r io.Reader) (content io.Reader, head []byte, err error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err = br.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, nil, err
+	}
+	return br, head, nil
+}
+
+// readHead returns the first bytes of the file at filePath, as sniff does, or
+// nil if the file cannot be read.
+func readHead(filePath string, open openFunc) []byte {
+	reader, _, err := open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+	_, head, err := sniff(reader)
+	if err != nil {
+		return nil
+	}
+	return bytes.Clone(head)
+}
+
+// listFiles drops the files of the sensitive files deny list from files, and
+// binary files unless the binary policy asks for them to be rendered.
+func (rc *repoCommon) listFiles(ctx context.Context, files []string, open openFunc) ([]string, error) {
+	files = rc.dropSensitive(files)
+	if rc.binary != BinarySkip {
+		return files, nil
+	}
+	text := files[:0]
+	for _, filePath := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Files that cannot be read are kept so that reading them later
+		// surfaces the error.
+		if !IsBinary(readHead(filePath, open)) {
+			text = append(text, filePath)
+		}
+	}
+	return text, nil
+}
+
+// renderBinary returns the contents to render for a binary file of the given
+// size according to the binary policy, and the encoding to report with it.
+// The returned reader must be closed.
+func (rc *repoCommon) renderBinary(content io.Reader, size int64) (io.ReadCloser, string) {
+	switch rc.binary {
+	case BinaryBase64:
+		return encodeReader(content, func(w io.Writer) io.WriteCloser {
+			return base64.NewEncoder(base64.StdEncoding, &lineWriter{w: w, width: 76})
+		}), string(BinaryBase64)
+	case BinaryHexdump:
+		return encodeReader(content, hex.Dumper), string(BinaryHexdump)
+	default:
+		return io.NopCloser(strings.NewReader(fmt.Sprintf("[binary file, %d bytes]", size))), ""
+	}
+}
+
+// encodeReader returns a reader of src passed through the encoder returned by
+// newEncoder. Encoding happens in a goroutine as the result is read.
+func encodeReader(src io.Reader, newEncoder func(io.Writer) io.WriteCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		enc := newEncoder(pw)
+		_, err := io.Copy(enc, src)
+		if closeErr := enc.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+	return &encodedReader{PipeReader: pr, done: done}
+}
+
+// encodedReader is the reading end of encodeReader. Closing it stops the
+// encoding goroutine and waits for it, so src is no longer in use.
+type encodedReader struct {
+	*io.PipeReader
+	done chan struct{}
+}
+
+func (r *encodedReader) Close() error {
+	err := r.PipeReader.Close()
+	<-r.done
+	return err
+}
+
+// lineWriter breaks its output into lines of at most width bytes.
+type lineWriter struct {
+	w      io.Writer
+	width  int
+	column int
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if lw.column == lw.width {
+			if _, err := io.WriteString(lw.w, "\n"); err != nil {
+				return written, err
+			}
+			lw.column = 0
+		}
+		n := min(len(p), lw.width-lw.column)
+		if _, err := lw.w.Write(p[:n]); err != nil {
+			return written, err
+		}
+		lw.column += n
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
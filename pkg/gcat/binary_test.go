@@ -0,0 +1,135 @@
+package gcat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBinary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{name: "empty", data: "", want: false},
+		{name: "ASCII text", data: "package main\n", want: false},
+		{name: "UTF-8 text", data: "héllo, 世界\n", want: false},
+		{name: "character cut off at the end of the sample", data: strings.Repeat("a", sniffLen-1) + "世", want: false},
+		{name: "NUL byte", data: "text\x00more text", want: true},
+		{name: "invalid UTF-8", data: "caf\xe9 au lait", want: true},
+		{name: "incomplete character at the end of the file", data: "caf\xc3", want: true},
+		{name: "PNG", data: "\x89PNG\r\n\x1a\n", want: true},
+		{name: "PDF", data: "%PDF-1.7\n%comment\n1 0 obj\n", want: true},
+		{name: "ZIP", data: "PK\x03\x04", want: true},
+		{name: "ELF", data: "\x7fELF", want: true},
+		{name: "NUL byte after the sample", data: strings.Repeat("a", sniffLen) + "\x00", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, IsBinary([]byte(tt.data)))
+		})
+	}
+}
+
+func TestParseBinaryPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range BinaryPolicyNames() {
+		policy, err := ParseBinaryPolicy(strings.ToUpper(name))
+		require.NoError(t, err)
+		assert.Equal(t, BinaryPolicy(name), policy)
+	}
+
+	_, err := ParseBinaryPolicy("raw")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "skip, placeholder, base64, hexdump")
+}
+
+func TestLocalRepository_BinaryPolicy(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("text"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.bin"), []byte("\x00\x01\x02ABCDEFGHIJKLMNOPQRSTUVWXYZ"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.txt"), []byte("more"), 0o644))
+
+	tests := []struct {
+		name      string
+		policy    BinaryPolicy
+		wantFiles []string
+		want      string
+	}{
+		{
+			name:      "binary files are skipped by default",
+			wantFiles: []string{"a.txt", "c.txt"},
+			want:      "a.txt (Text):\n\n<contents>\ntext\n</contents>\n\n---\n\nc.txt (Text):\n\n<contents>\nmore\n</contents>",
+		},
+		{
+			name:      "skip",
+			policy:    BinarySkip,
+			wantFiles: []string{"a.txt", "c.txt"},
+			want:      "a.txt (Text):\n\n<contents>\ntext\n</contents>\n\n---\n\nc.txt (Text):\n\n<contents>\nmore\n</contents>",
+		},
+		{
+			name:      "placeholder",
+			policy:    BinaryPlaceholder,
+			wantFiles: []string{"a.txt", "b.bin", "c.txt"},
+			want:      "a.txt (Text):\n\n<contents>\ntext\n</contents>\n\n---\n\nb.bin:\n\n<contents>\n[binary file, 29 bytes]\n</contents>\n\n---\n\nc.txt (Text):\n\n<contents>\nmore\n</contents>",
+		},
+		{
+			name:      "base64",
+			policy:    BinaryBase64,
+			wantFiles: []string{"a.txt", "b.bin", "c.txt"},
+			want:      "a.txt (Text):\n\n<contents>\ntext\n</contents>\n\n---\n\nb.bin (base64):\n\n<contents>\nAAECQUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVo=\n</contents>\n\n---\n\nc.txt (Text):\n\n<contents>\nmore\n</contents>",
+		},
+		{
+			name:      "hexdump",
+			policy:    BinaryHexdump,
+			wantFiles: []string{"a.txt", "b.bin", "c.txt"},
+			want: "a.txt (Text):\n\n<contents>\ntext\n</contents>\n\n---\n\nb.bin (hexdump):\n\n<contents>\n" +
+				"00000000  00 01 02 41 42 43 44 45  46 47 48 49 4a 4b 4c 4d  |...ABCDEFGHIJKLM|\n" +
+				"00000010  4e 4f 50 51 52 53 54 55  56 57 58 59 5a           |NOPQRSTUVWXYZ|\n" +
+				"\n</contents>\n\n---\n\nc.txt (Text):\n\n<contents>\nmore\n</contents>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, err := NewLocalRepository(dir, WithBinaryPolicy(tt.policy))
+			require.NoError(t, err)
+
+			files, err := repo.GetFiles()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFiles, files)
+
+			got, err := repo.ConcatFiles([]string{"a.txt", "b.bin", "c.txt"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	lw := &lineWriter{w: &sb, width: 4}
+	for _, chunk := range []string{"abc", "defgh", "ij", "kl"} {
+		n, err := lw.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.Equal(t, "abcd\nefgh\nijkl", sb.String())
+}
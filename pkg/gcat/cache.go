@@ -0,0 +1,363 @@
+package gcat
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/storage"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+const (
+	// cacheUsedFile is the file whose modification time records when a cached
+	// repository was last used.
+	cacheUsedFile = "gcat-used"
+	// staleCacheLock is how old a lock must be before it is taken to have
+	// been left behind by a process that did not finish.
+	staleCacheLock = time.Hour
+)
+
+// cacheRefSpecs fetch every branch of the remote, and the branch its HEAD
+// points to, as remote-tracking branches. A full fetch uses them along with
+// git.AllTags.
+var cacheRefSpecs = []config.RefSpec{
+	"+refs/heads/*:refs/remotes/origin/*",
+	"+HEAD:refs/remotes/origin/HEAD",
+}
+
+// errCacheBusy is returned when another process is fetching into the cached
+// clone of a repository.
+var errCacheBusy = errors.New("cached clone is in use")
+
+// CloneCache keeps clones of remote repositories on disk, under Dir, so that
+// only the commits made since the last run are fetched. Each repository is a
+// bare Git repository, in a directory named after a hash of its URL, with
+// the refs fetched into it so far. Those are fetched at depth 1 until a full
+// history is needed, which fetches every branch and tag with all of their
+// commits once and keeps fetching the history from then on.
+type CloneCache struct {
+	Dir string
+}
+
+// DefaultCloneCache returns the clone cache under "gcat/repositories" in the
+// user cache directory.
+func DefaultCloneCache() (*CloneCache, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &CloneCache{Dir: filepath.Join(cacheDir, "gcat", "repositories")}, nil
+}
+
+// WithCloneCache makes CloneGitRepository and OpenDiff fetch remote
+// repositories into c, reusing what earlier runs fetched, instead of cloning
+// them into memory. URLs with a password in them are still cloned into
+// memory, so that it is not stored on disk, as are repositories another
+// process is fetching at the same time. It has no effect on local
+// directories.
+func WithCloneCache(c *CloneCache) Option {
+	return func(r Repository) {
+		if gr, ok := r.(*gitRepository); ok {
+			gr.cache = c
+		}
+	}
+}
+
+// CachedRepository describes a repository in a CloneCache.
+type CachedRepository struct {
+	URL  string
+	Path string
+	// Size is the number of bytes the repository takes on disk.
+	Size     int64
+	LastUsed time.Time
+}
+
+// List returns the repositories in the cache, most recently used first.
+func (c *CloneCache) List() ([]CachedRepository, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []CachedRepository
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		repo, err := c.describe(filepath.Join(c.Dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].LastUsed.After(repos[j].LastUsed) })
+	return repos, nil
+}
+
+// describe returns the description of the cached repository at path.
+func (c *CloneCache) describe(path string) (CachedRepository, error) {
+	repo := CachedRepository{Path: path}
+	if gitRepo, err := git.PlainOpen(path); err == nil {
+		if remote, err := gitRepo.Remote(git.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+			repo.URL = remote.Config().URLs[0]
+		}
+	}
+	if info, err := os.Stat(filepath.Join(path, cacheUsedFile)); err == nil {
+		repo.LastUsed = info.ModTime()
+	} else if info, err := os.Stat(path); err == nil {
+		repo.LastUsed = info.ModTime()
+	}
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			repo.Size += info.Size()
+		}
+		return nil
+	})
+	return repo, err
+}
+
+// Prune removes the repositories that were not used within olderThan, other
+// than those being fetched, and returns them.
+func (c *CloneCache) Prune(olderThan time.Duration) ([]CachedRepository, error) {
+	repos, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	var pruned []CachedRepository
+	for _, repo := range repos {
+		if !repo.LastUsed.Before(cutoff) {
+			continue
+		}
+		if _, err := os.Stat(repo.Path + ".lock"); err == nil {
+			continue
+		}
+		if err := os.RemoveAll(repo.Path); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, repo)
+	}
+	return pruned, nil
+}
+
+// Clear removes every repository from the cache.
+func (c *CloneCache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
+
+// path returns the directory repoURL is cached in. URLs that differ only by
+// a trailing slash or ".git" share it.
+func (c *CloneCache) path(repoURL string) string {
+	key := strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git")
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:16]))
+}
+
+// cacheable reports whether repoURL may be cached, which it may not when it
+// has a password in it.
+func cacheable(repoURL string) bool {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.User == nil {
+		return true
+	}
+	_, hasPassword := u.User.Password()
+	return !hasPassword
+}
+
+// fetch brings refSpec of the cached clone of repoURL up to date, cloning it
+// first if it is not cached yet, and opens it. An empty refSpec fetches every
+// branch and tag with all of their history. It returns errCacheBusy when
+// another process is fetching into it.
+func (c *CloneCache) fetch(ctx context.Context, repoURL string, auth transport.AuthMethod, refSpec config.RefSpec) (*git.Repository, error) {
+	path := c.path(repoURL)
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	unlock, err := lockCache(path + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	st, err := fetchInto(ctx, path, repoURL, auth, refSpec, false)
+	if errors.Is(err, errShallowCache) {
+		st, err = fetchInto(ctx, path, repoURL, auth, refSpec, true)
+	}
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(filepath.Join(path, cacheUsedFile), now, now); errors.Is(err, fs.ErrNotExist) {
+		os.WriteFile(filepath.Join(path, cacheUsedFile), nil, 0o644)
+	}
+	return git.Open(&lockedStorer{Storer: st}, nil)
+}
+
+// errShallowCache is returned by fetchInto when the cached clone is shallow
+// and what is fetched needs it not to be.
+var errShallowCache = errors.New("cached clone is shallow")
+
+// fetchInto fetches refSpec, or everything when it is empty, into the clone
+// at path, creating it first if it does not exist or restart is set. A ref is
+// fetched at depth 1 into a new or shallow clone.
+//
+// go-git cannot deepen a shallow clone, and tells the remote that it has the
+// history behind the commits of one. A full fetch into a shallow clone, or a
+// ref whose commit is behind those, returns errShallowCache so that it starts
+// over from an empty clone.
+func fetchInto(ctx context.Context, path, repoURL string, auth transport.AuthMethod, refSpec config.RefSpec, restart bool) (*filesystem.Storage, error) {
+	if restart {
+		if err := os.RemoveAll(path); err != nil {
+			return nil, err
+		}
+	}
+	st := filesystem.NewStorage(osfs.New(path), cache.NewObjectLRUDefault())
+	gitRepo, err := git.Open(st, nil)
+	created := false
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		if gitRepo, err = git.Init(st, nil); err == nil {
+			created = true
+			_, err = gitRepo.CreateRemote(&config.RemoteConfig{
+				Name:  git.DefaultRemoteName,
+				URLs:  []string{repoURL},
+				Fetch: cacheRefSpecs,
+			})
+		}
+	}
+	var shallow []plumbing.Hash
+	if err == nil {
+		shallow, err = st.Shallow()
+	}
+	if err == nil && len(shallow) > 0 && refSpec == "" {
+		return nil, errShallowCache
+	}
+	if err == nil {
+		opts := &git.FetchOptions{
+			RefSpecs: cacheRefSpecs,
+			Auth:     auth,
+			Tags:     git.AllTags,
+			Force:    true,
+		}
+		if refSpec != "" {
+			opts.RefSpecs = []config.RefSpec{refSpec}
+			opts.Tags = git.NoTags
+			if created || len(shallow) > 0 {
+				opts.Depth = 1
+			}
+		}
+		err = gitRepo.FetchContext(ctx, opts)
+		if errors.Is(err, git.NoErrAlreadyUpToDate) {
+			err = nil
+		}
+	}
+	if err == nil && refSpec != "" {
+		var ref *plumbing.Reference
+		if ref, err = st.Reference(refSpec.Dst(plumbing.ReferenceName(refSpec.Src()))); err == nil && st.HasEncodedObject(ref.Hash()) != nil {
+			err = errShallowCache
+		}
+	}
+	if errors.Is(err, plumbing.ErrObjectNotFound) && len(shallow) > 0 {
+		err = errShallowCache
+	}
+	if err != nil {
+		if created {
+			// Leave no half-fetched clone behind for the next run to trust.
+			os.RemoveAll(path)
+		}
+		return nil, err
+	}
+	return st, nil
+}
+
+// lockCache creates the lock file at path, removing it first if it is stale,
+// and returns a function removing it.
+func lockCache(path string) (func(), error) {
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		info, statErr := os.Stat(path)
+		if attempt > 0 || statErr != nil || time.Since(info.ModTime()) < staleCacheLock {
+			return nil, errCacheBusy
+		}
+		os.Remove(path)
+	}
+}
+
+// lockedStorer serializes reading the objects of a cached clone, which
+// go-git's filesystem storage does not support from several goroutines at
+// once. It reads each object into memory while it holds the lock, since the
+// objects of the filesystem storage read their packfile lazily.
+type lockedStorer struct {
+	storage.Storer
+	mu sync.Mutex
+}
+
+func (s *lockedStorer) EncodedObject(t plumbing.ObjectType, h plumbing.Hash) (plumbing.EncodedObject, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, err := s.Storer.EncodedObject(t, h)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := obj.(*plumbing.MemoryObject); ok {
+		return obj, nil
+	}
+
+	reader, err := obj.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	mem := &plumbing.MemoryObject{}
+	mem.SetType(obj.Type())
+	if _, err := io.Copy(mem, reader); err != nil {
+		return nil, err
+	}
+	return mem, nil
+}
+
+func (s *lockedStorer) EncodedObjectSize(h plumbing.Hash) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Storer.EncodedObjectSize(h)
+}
+
+func (s *lockedStorer) HasEncodedObject(h plumbing.Hash) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Storer.HasEncodedObject(h)
+}
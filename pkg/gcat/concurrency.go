@@ -0,0 +1,123 @@
+package gcat
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// maxBufferedFile is the size above which a file is not read ahead of its
+// turn, but opened again and streamed to the output once it is its turn.
+const maxBufferedFile = 1 << 20
+
+// WithConcurrency sets how many files ConcatFiles and ConcatTo read and
+// process at once, outlining and redacting them ahead of their turn, while
+// still writing them one after another in sorted order. At most n files of up
+// to 1 MiB are held in memory ahead of their turn; larger files are only read
+// once it is their turn, as with n of 1, where each file is streamed straight
+// to the output. Outlining needs the whole contents of a file, so it holds
+// the file being written in memory either way; redaction streams larger files
+// too. The default is runtime.GOMAXPROCS(0).
+func WithConcurrency(n int) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil {
+			rc.concurrency = max(n, 1)
+		}
+	}
+}
+
+// sequentialFiles returns a function preparing files one at a time, in order,
+// each when it is asked for.
+func (rc *repoCommon) sequentialFiles(ctx context.Context, files []string, open openFunc) func() (*preparedFile, error) {
+	next := 0
+	return func() (*preparedFile, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		filePath := files[next]
+		next++
+		return rc.prepareFile(ctx, filePath, open)
+	}
+}
+
+// parallelFiles prepares files with up to rc.concurrency workers, reading
+// their contents into memory, and returns a function handing them out in
+// order. Files over maxBufferedFile are instead prepared when they are handed
+// out. A file's slot is freed once it has been handed out, which bounds how
+// far the workers run ahead of the writer. Canceling ctx stops the workers.
+func (rc *repoCommon) parallelFiles(ctx context.Context, files []string, open openFunc) func() (*preparedFile, error) {
+	type result struct {
+		p   *preparedFile
+		err error
+		// large is set instead of p for files left to be prepared when they
+		// are handed out.
+		large bool
+	}
+	slots := make(chan struct{}, rc.concurrency)
+	pending := make(chan chan result, rc.concurrency)
+	go func() {
+		defer close(pending)
+		for _, filePath := range files {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			done := make(chan result, 1)
+			pending <- done
+			go func() {
+				reader, size, err := open(filePath)
+				if err != nil {
+					done <- result{err: err}
+					return
+				}
+				if size > maxBufferedFile {
+					reader.Close()
+					done <- result{large: true}
+					return
+				}
+				opened := func(string) (io.ReadCloser, int64, error) { return reader, size, nil }
+				p, err := rc.prepareFile(ctx, filePath, opened)
+				if err == nil {
+					if err = rc.bufferFile(p); err != nil {
+						p = nil
+					}
+				}
+				done <- result{p: p, err: err}
+			}()
+		}
+	}()
+
+	next := 0
+	return func() (*preparedFile, error) {
+		done, ok := <-pending
+		if !ok {
+			return nil, ctx.Err()
+		}
+		r := <-done
+		<-slots
+		filePath := files[next]
+		next++
+		if r.large {
+			return rc.prepareFile(ctx, filePath, open)
+		}
+		return r.p, r.err
+	}
+}
+
+// bufferFile reads the contents of p into memory, unless the binary policy
+// leaves them out, and releases the file they were read from.
+func (rc *repoCommon) bufferFile(p *preparedFile) error {
+	release := p.close
+	p.close = func() error { return nil }
+	defer release()
+	if p.binary && (rc.binary == BinarySkip || rc.binary == BinaryPlaceholder) {
+		return nil
+	}
+	content, err := io.ReadAll(p.file.Content)
+	if err != nil {
+		return err
+	}
+	p.file.Content = bytes.NewReader(content)
+	return nil
+}
@@ -0,0 +1,222 @@
+package gcat
+
+import (
+	"bytes"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/timsexperiments/gcat/pkg/lang"
+)
+
+// filenamePrefixLanguages maps file name prefixes to their languages, for
+// variants such as "Dockerfile.dev" that the language table cannot list.
+var filenamePrefixLanguages = map[string]string{
+	"Dockerfile.":    "Dockerfile",
+	"Containerfile.": "Dockerfile",
+	"Jenkinsfile.":   "Groovy",
+	"Makefile.":      "Makefile",
+	".env.":          "Dotenv",
+}
+
+// languageHeuristics disambiguate extensions shared by several languages by
+// looking at the start of the file. They return "" when the content gives no
+// clue, leaving the extension map to decide.
+var languageHeuristics = map[string]func(head []byte) string{
+	".h":  headerLanguage,
+	".m":  mLanguage,
+	".v":  vLanguage,
+	".pl": plLanguage,
+}
+
+var (
+	objectiveCPattern = regexp.MustCompile(`(?m)^\s*(@(interface|implementation|protocol|end|property|class)\b|#import\s)`)
+	cppPattern        = regexp.MustCompile(`(?m)^\s*((class|namespace|template)\b|#include\s*<(iostream|string|vector|map|memory|cstdint|cstdio|cstdlib)>|using\s+namespace\b)|\bstd::`)
+	matlabPattern     = regexp.MustCompile(`(?m)^\s*(function\b.*=|function\s+\w+\s*\(|%|end\s*$|classdef\b)`)
+	verilogPattern    = regexp.MustCompile(`(?m)^\s*(module\s+\w+\s*[#(;]|endmodule\b|always\s*@|(input|output|wire|reg)\b)`)
+	coqPattern        = regexp.MustCompile(`(?m)^\s*(Theorem|Lemma|Proof|Qed|Require\s+Import|Inductive|Fixpoint|Definition)\b`)
+	vlangPattern      = regexp.MustCompile(`(?m)^\s*(fn\s+\w+\s*\(|module\s+\w+\s*$|import\s+\w+\s*$)`)
+	prologPattern     = regexp.MustCompile(`(?m)^\s*:-|^\s*[a-z]\w*(\(.*\))?\s*:-`)
+	perlPattern       = regexp.MustCompile(`(?m)^\s*(use\s+(strict|warnings)\b|my\s+[$@%]|sub\s+\w+|package\s+[\w:]+;)`)
+)
+
+func headerLanguage(head []byte) string {
+	switch {
+	case objectiveCPattern.Match(head):
+		return "Objective-C"
+	case cppPattern.Match(head):
+		return "C++"
+	}
+	return "C"
+}
+
+func mLanguage(head []byte) string {
+	if !objectiveCPattern.Match(head) && matlabPattern.Match(head) {
+		return "MATLAB"
+	}
+	return "Objective-C"
+}
+
+func vLanguage(head []byte) string {
+	switch {
+	case verilogPattern.Match(head):
+		return "Verilog"
+	case coqPattern.Match(head):
+		return "Coq"
+	case vlangPattern.Match(head):
+		return "V"
+	}
+	return ""
+}
+
+func plLanguage(head []byte) string {
+	switch {
+	case perlPattern.Match(head):
+		return "Perl"
+	case prologPattern.Match(head):
+		return "Prolog"
+	}
+	return ""
+}
+
+// detectLanguage identifies the language of filePath. head is the start of
+// the file's contents, or nil when they are not available. Like GitHub
+// Linguist, it tries in turn Vim and Emacs modelines, exact file names,
+// shebang interpreters and finally the extension map, using content
+// heuristics for ambiguous extensions such as ".h" that have not been
+// overridden with WithRegisteredLanguages. Without contents, an ambiguous
+// extension maps to its primary language in the lang package.
+func (rc *repoCommon) detectLanguage(filePath string, head []byte) string {
+	if lang := rc.modelineLanguage(head); lang != "" {
+		return lang
+	}
+
+	name := path.Base(filepath.ToSlash(filePath))
+	if l, ok := lang.ByFilename(name); ok {
+		return l.Name
+	}
+	for prefix, language := range filenamePrefixLanguages {
+		if strings.HasPrefix(name, prefix) {
+			return language
+		}
+	}
+
+	if language := shebangLanguage(head); language != "" {
+		return language
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	language := rc.languages[ext]
+	if heuristic, ok := languageHeuristics[ext]; ok && head != nil && language == primaryLanguage(ext) {
+		if guess := heuristic(head); guess != "" {
+			return guess
+		}
+	}
+	return language
+}
+
+// primaryLanguage returns the most likely language of files with extension
+// ext, or "" if it is unknown.
+func primaryLanguage(ext string) string {
+	if candidates := lang.ByExtension(ext); len(candidates) > 0 {
+		return candidates[0].Name
+	}
+	return ""
+}
+
+// shebangLanguage returns the language of the interpreter named on the "#!"
+// line at the start of head, looking through env and its options.
+func shebangLanguage(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+	line, _, _ := bytes.Cut(head[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = path.Base(field)
+			break
+		}
+	}
+	if l, ok := lang.ByInterpreter(interpreter); ok {
+		return l.Name
+	}
+	if l, ok := lang.ByInterpreter(strings.TrimRight(interpreter, "0123456789.-")); ok {
+		return l.Name
+	}
+	return ""
+}
+
+var (
+	// vimModeline matches "vim: set ft=python:" and "vi: filetype=sh".
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex)[0-9]*[:=].*?\b(?:ft|filetype|syntax)=([\w+#.-]+)`)
+	// emacsModeline matches "-*- mode: python -*-" and "-*- python -*-".
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?\bmode:\s*([\w+#.-]+)|([\w+#.-]+))\s*(?:;.*?)?-\*-`)
+)
+
+// modelineLanguage returns the language named by a Vim or Emacs modeline in
+// the first or last five lines of head.
+func (rc *repoCommon) modelineLanguage(head []byte) string {
+	lines := bytes.Split(head, []byte("\n"))
+	if len(lines) > 10 {
+		lines = append(lines[:5], lines[len(lines)-5:]...)
+	}
+	for _, line := range lines {
+		var name string
+		if m := vimModeline.FindSubmatch(line); m != nil {
+			name = string(m[1])
+		} else if m := emacsModeline.FindSubmatch(line); m != nil {
+			name = string(m[1]) + string(m[2])
+		}
+		if name == "" {
+			continue
+		}
+		if language := rc.languageByAlias(name); language != "" {
+			return language
+		}
+	}
+	return ""
+}
+
+// languageByAlias returns the language an editor calls name, such as Vim's
+// "cpp" or Emacs's "emacs-lisp", matching the name or an alias of a language
+// from the lang package or a language registered with
+// WithRegisteredLanguages.
+func (rc *repoCommon) languageByAlias(name string) string {
+	name = strings.TrimSuffix(name, "-mode")
+	for _, candidate := range []string{name, strings.ReplaceAll(name, "-", " ")} {
+		if l, ok := lang.Lookup(candidate); ok {
+			return l.Name
+		}
+	}
+	for _, language := range rc.languages {
+		if strings.EqualFold(language, name) {
+			return language
+		}
+	}
+	return ""
+}
+
+// FilesOfType returns the files, in their original order, whose detected
+// language has one of the given types, such as lang.Programming for all
+// source code. Files in unknown languages are dropped.
+func FilesOfType(repo Repository, files []string, types ...lang.Type) []string {
+	var matched []string
+	for _, filePath := range files {
+		l, ok := lang.Lookup(repo.GetLanguage(filePath))
+		if ok && slices.Contains(types, l.Type) {
+			matched = append(matched, filePath)
+		}
+	}
+	return matched
+}
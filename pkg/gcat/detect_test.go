@@ -0,0 +1,129 @@
+package gcat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timsexperiments/gcat/pkg/lang"
+)
+
+func TestRepoCommon_DetectLanguage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filePath string
+		content  string
+		want     string
+	}{
+		// File names
+		{name: "Dockerfile", filePath: "build/Dockerfile", want: "Dockerfile"},
+		{name: "Dockerfile variant", filePath: "Dockerfile.dev", want: "Dockerfile"},
+		{name: "Makefile", filePath: "Makefile", want: "Makefile"},
+		{name: "Jenkinsfile", filePath: "Jenkinsfile", want: "Groovy"},
+		{name: "go.mod", filePath: "go.mod", want: "Go Module"},
+		{name: "bashrc", filePath: "home/.bashrc", want: "Shell"},
+		{name: "file names are case-sensitive", filePath: "dockerfile", want: ""},
+
+		// Shebangs
+		{name: "env python", filePath: "bin/tool", content: "#!/usr/bin/env python3\nprint('hi')\n", want: "Python"},
+		{name: "env with options", filePath: "tool", content: "#!/usr/bin/env -S node --experimental-modules\n", want: "JavaScript"},
+		{name: "absolute interpreter", filePath: "run", content: "#!/bin/bash -e\n", want: "Shell"},
+		{name: "versioned interpreter", filePath: "run", content: "#!/usr/local/bin/python3.12\n", want: "Python"},
+		{name: "shebang overrides the extension", filePath: "script.txt", content: "#!/bin/sh\n", want: "Shell"},
+		{name: "unknown interpreter", filePath: "run", content: "#!/usr/bin/frobnicate\n", want: ""},
+
+		// Modelines
+		{name: "vim modeline", filePath: "config", content: "# settings\n# vim: set ft=python:\n", want: "Python"},
+		{name: "vim filetype at the end", filePath: "x.txt", content: "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n// vim: ts=4 filetype=javascript\n", want: "JavaScript"},
+		{name: "emacs mode", filePath: "notes", content: "# -*- mode: ruby; coding: utf-8 -*-\n", want: "Ruby"},
+		{name: "emacs short form", filePath: "build.inc", content: "# -*- makefile -*-\n", want: "Makefile"},
+		{name: "emacs coding only", filePath: "notes", content: "# -*- coding: utf-8 -*-\n", want: ""},
+		{name: "modeline overrides the file name", filePath: "Makefile", content: "# vim: ft=sh\n", want: "Shell"},
+
+		// Heuristics
+		{name: "C header", filePath: "lib.h", content: "#include <stdio.h>\nint f(void);\n", want: "C"},
+		{name: "C++ header", filePath: "lib.h", content: "#pragma once\nnamespace lib {\nclass A {};\n}\n", want: "C++"},
+		{name: "Objective-C header", filePath: "View.h", content: "#import <UIKit/UIKit.h>\n@interface View : UIView\n@end\n", want: "Objective-C"},
+		{name: "header without contents", filePath: "lib.h", want: "C"},
+		{name: "Objective-C implementation", filePath: "View.m", content: "#import \"View.h\"\n@implementation View\n@end\n", want: "Objective-C"},
+		{name: "MATLAB", filePath: "solve.m", content: "function x = solve(a, b)\n% Solve a linear system.\nx = a \\ b;\nend\n", want: "MATLAB"},
+		{name: "Verilog", filePath: "counter.v", content: "module counter(input clk, output reg [3:0] q);\nalways @(posedge clk) q <= q + 1;\nendmodule\n", want: "Verilog"},
+		{name: "Coq", filePath: "Proofs.v", content: "Require Import Arith.\nTheorem plus_0 : forall n, n + 0 = n.\nProof. auto. Qed.\n", want: "Coq"},
+		{name: "V", filePath: "main.v", content: "module main\n\nfn main() {\n\tprintln('hi')\n}\n", want: "V"},
+		{name: "Perl", filePath: "tool.pl", content: "use strict;\nmy $x = 1;\n", want: "Perl"},
+		{name: "Prolog", filePath: "family.pl", content: "parent(tom, bob).\ngrandparent(X, Z) :- parent(X, Y), parent(Y, Z).\n", want: "Prolog"},
+		{name: "no clue falls back to the extension", filePath: "x.pl", content: "1;\n", want: "Perl"},
+
+		// Extensions
+		{name: "extension", filePath: "main.go", content: "package main\n", want: "Go"},
+		{name: "unknown", filePath: "data.xyz", content: "???", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var head []byte
+			if tt.content != "" {
+				head = []byte(tt.content)
+			}
+			assert.Equal(t, tt.want, newRepoCommon().detectLanguage(tt.filePath, head))
+		})
+	}
+}
+
+func TestRepoCommon_DetectLanguageRegisteredExtension(t *testing.T) {
+	t.Parallel()
+
+	rc := newRepoCommon()
+	rc.registerLanguage(".v", "Vue Template")
+
+	assert.Equal(t, "Vue Template", rc.detectLanguage("counter.v", []byte("module counter;\nendmodule\n")))
+}
+
+func TestLocalRepository_GetLanguageFromContent(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deploy"), []byte("#!/usr/bin/env bash\nset -e\n"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.h"), []byte("template <typename T> T max(T a, T b);\n"), 0o644))
+
+	repo, err := NewLocalRepository(dir, WithFormatter(JSONLFormatter{}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Shell", repo.GetLanguage("deploy"))
+	assert.Equal(t, "C++", repo.GetLanguage("lib.h"))
+	assert.Equal(t, "Go", repo.GetLanguage("missing.go"))
+
+	got, err := repo.ConcatFiles([]string{"deploy"})
+	require.NoError(t, err)
+	assert.Contains(t, got, `"language":"Shell"`)
+}
+
+func TestFilesOfType(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":    "package main\n",
+		"README.md":  "# readme\n",
+		"data.json":  "{}\n",
+		"build":      "#!/bin/sh\nmake\n",
+		"notes.xyz":  "unknown\n",
+		"Dockerfile": "FROM scratch\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	repo, err := NewLocalRepository(dir)
+	require.NoError(t, err)
+	files, err := repo.GetFiles()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Dockerfile", "build", "main.go"}, FilesOfType(repo, files, lang.Programming))
+	assert.Equal(t, []string{"README.md", "data.json"}, FilesOfType(repo, files, lang.Prose, lang.Data))
+	assert.Empty(t, FilesOfType(repo, files, lang.Markup))
+}
@@ -0,0 +1,541 @@
+package gcat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	fdiff "gopkg.in/src-d/go-git.v4/plumbing/format/diff"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/diff"
+	"gopkg.in/src-d/go-git.v4/utils/merkletrie"
+)
+
+// DiffMode decides what Diff.ConcatTo writes for each changed file.
+type DiffMode string
+
+// Diff modes accepted by Diff.ConcatTo and ParseDiffMode.
+const (
+	// DiffContents writes the new contents of each changed file, as
+	// ConcatFiles does. Deleted files are listed without contents.
+	DiffContents DiffMode = "contents"
+	// DiffPatch writes a unified diff of each changed file.
+	DiffPatch DiffMode = "patch"
+	// DiffBoth writes the new contents of each changed file followed by its
+	// unified diff.
+	DiffBoth DiffMode = "both"
+)
+
+var diffModes = []DiffMode{DiffContents, DiffPatch, DiffBoth}
+
+// ParseDiffMode returns the diff mode with the given name.
+func ParseDiffMode(name string) (DiffMode, error) {
+	for _, mode := range diffModes {
+		if strings.EqualFold(name, string(mode)) {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown diff mode %q (available: %s)", name, strings.Join(DiffModeNames(), ", "))
+}
+
+// DiffModeNames returns the names of the diff modes.
+func DiffModeNames() []string {
+	names := make([]string, len(diffModes))
+	for i, mode := range diffModes {
+		names[i] = string(mode)
+	}
+	return names
+}
+
+// ChangeStatus describes how a file changed between two versions.
+type ChangeStatus string
+
+const (
+	ChangeAdded    ChangeStatus = "added"
+	ChangeModified ChangeStatus = "modified"
+	ChangeDeleted  ChangeStatus = "deleted"
+)
+
+// Change is a file that differs between the base and head of a Diff.
+type Change struct {
+	// Path is the path of the file in head, or in base if it was deleted.
+	Path   string
+	Status ChangeStatus
+}
+
+// Diff is the set of files changed between two versions of a repository,
+// base and head.
+type Diff struct {
+	common  *repoCommon
+	changes []Change
+	base    openFunc
+	head    openFunc
+}
+
+// ParseRange splits a revision range "<base>..<head>" into its base and
+// head. An empty base means HEAD, and an empty head means the working tree
+// for local folders and HEAD for remote repositories, so "main.." and "main"
+// both compare the working tree against main. Symmetric ranges ("a...b") are
+// not supported.
+func ParseRange(spec string) (base, head string, err error) {
+	if strings.Contains(spec, "...") {
+		return "", "", fmt.Errorf("range %q: symmetric ranges (a...b) are not supported", spec)
+	}
+	base, head, _ = strings.Cut(spec, "..")
+	if base == "" {
+		base = "HEAD"
+	}
+	return base, head, nil
+}
+
+// OpenDiff lists the files changed between the commits base and head of the
+// repository or folder at pathOrURL, resolving each like WithRef does. For
+// a local folder inside a Git working tree, an empty head compares the files
+// in the working tree, staged or not, against base; paths are relative to
+// the folder, and changes outside of it are left out. For a remote
+// repository, which is cloned with its full history, an empty head means
+// the default branch.
+//
+// The options configure how the files are written, as they do for
+// OpenRepository; WithRef and WithGitIndex have no effect.
+func OpenDiff(pathOrURL, base, head string, opts ...Option) (*Diff, error) {
+	return OpenDiffContext(context.Background(), pathOrURL, base, head, opts...)
+}
+
+// OpenDiffContext is OpenDiff, giving up cloning a remote repository once ctx
+// is done.
+func OpenDiffContext(ctx context.Context, pathOrURL, base, head string, opts ...Option) (*Diff, error) {
+	if IsRemoteURL(pathOrURL) {
+		return cloneDiff(ctx, pathOrURL, base, head, opts...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return openLocalDiff(pathOrURL, base, head, opts...)
+}
+
+func openLocalDiff(root, base, head string, opts ...Option) (*Diff, error) {
+	repo, err := NewLocalRepository(root, opts...)
+	if err != nil {
+		return nil, err
+	}
+	l := repo.(*localRepository)
+
+	gitRepo, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening Git repository at %s: %w", root, err)
+	}
+	wt, err := gitRepo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := worktreePrefix(wt.Filesystem.Root(), root)
+	if err != nil {
+		return nil, err
+	}
+
+	baseRepo, err := commitRepository(gitRepo, l.common, base)
+	if err != nil {
+		return nil, err
+	}
+	d := &Diff{common: l.common, base: prefixed(baseRepo.open, prefix)}
+	if head == "" {
+		d.head = l.open
+		d.changes, err = worktreeChanges(gitRepo, baseRepo, prefix, root)
+	} else {
+		var headRepo *gitRepository
+		if headRepo, err = commitRepository(gitRepo, l.common, head); err != nil {
+			return nil, err
+		}
+		d.head = prefixed(headRepo.open, prefix)
+		d.changes, err = treeChanges(baseRepo, headRepo, prefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func cloneDiff(ctx context.Context, repoURL, base, head string, opts ...Option) (*Diff, error) {
+	repo := &gitRepository{common: newRepoCommon()}
+	repo.common.getFiles = repo.GetFilesContext
+	for _, opt := range opts {
+		opt(repo)
+	}
+	gitRepo, err := repo.fullClone(ctx, repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if head == "" {
+		head = "HEAD"
+	}
+	baseRepo, err := commitRepository(gitRepo, repo.common, base)
+	if err != nil {
+		return nil, err
+	}
+	headRepo, err := commitRepository(gitRepo, repo.common, head)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := treeChanges(baseRepo, headRepo, "")
+	if err != nil {
+		return nil, err
+	}
+	repo.repo, repo.commit = gitRepo, headRepo.commit
+	return &Diff{common: repo.common, changes: changes, base: baseRepo.open, head: headRepo.open}, nil
+}
+
+// commitRepository returns the files of repo at rev, sharing rc.
+func commitRepository(repo *git.Repository, rc *repoCommon, rev string) (*gitRepository, error) {
+	commit, err := resolveCommit(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	return &gitRepository{repo: repo, common: rc, commit: commit}, nil
+}
+
+// prefixed opens the files below prefix, a slash-separated directory with a
+// trailing slash, with open.
+func prefixed(open openFunc, prefix string) openFunc {
+	return func(filePath string) (io.ReadCloser, int64, error) {
+		return open(prefix + filepath.ToSlash(filePath))
+	}
+}
+
+// treeChanges lists the files below prefix that differ between the commits
+// of from and to, relative to prefix.
+func treeChanges(from, to *gitRepository, prefix string) ([]Change, error) {
+	fromTree, err := from.tree()
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := to.tree()
+	if err != nil {
+		return nil, err
+	}
+	treeChanges, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, c := range treeChanges {
+		action, err := c.Action()
+		if err != nil {
+			return nil, err
+		}
+		name, status := c.To.Name, ChangeModified
+		switch action {
+		case merkletrie.Insert:
+			status = ChangeAdded
+		case merkletrie.Delete:
+			name, status = c.From.Name, ChangeDeleted
+		case merkletrie.Modify:
+			if c.From.TreeEntry.Hash == c.To.TreeEntry.Hash {
+				continue // only the mode changed
+			}
+		}
+		if rel, ok := strings.CutPrefix(name, prefix); ok {
+			changes = append(changes, Change{Path: filepath.FromSlash(rel), Status: status})
+		}
+	}
+	sortChanges(changes)
+	return changes, nil
+}
+
+// worktreeChanges lists the files below prefix that differ between the
+// commit of base and the working tree of repo, whose folder root is at
+// prefix, like "git diff <base>" does: a file is in the working tree if it
+// is tracked in the index and exists on disk.
+func worktreeChanges(repo *git.Repository, base *gitRepository, prefix, root string) ([]Change, error) {
+	tree, err := base.tree()
+	if err != nil {
+		return nil, err
+	}
+	baseFiles := make(map[string]*object.File)
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if strings.HasPrefix(f.Name, prefix) {
+			baseFiles[f.Name] = f
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	tracked := make(map[string]bool, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		if entry.Mode != filemode.Submodule && strings.HasPrefix(entry.Name, prefix) {
+			tracked[entry.Name] = true
+		}
+	}
+
+	names := make([]string, 0, len(baseFiles)+len(tracked))
+	for name := range baseFiles {
+		names = append(names, name)
+	}
+	for name := range tracked {
+		if baseFiles[name] == nil {
+			names = append(names, name)
+		}
+	}
+
+	var changes []Change
+	for _, name := range names {
+		rel := filepath.FromSlash(strings.TrimPrefix(name, prefix))
+		var data []byte
+		onDisk := false
+		if tracked[name] {
+			data, err = os.ReadFile(filepath.Join(root, rel))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			onDisk = err == nil
+		}
+
+		baseFile := baseFiles[name]
+		switch {
+		case baseFile == nil && onDisk:
+			changes = append(changes, Change{Path: rel, Status: ChangeAdded})
+		case baseFile != nil && !onDisk:
+			changes = append(changes, Change{Path: rel, Status: ChangeDeleted})
+		case baseFile != nil && plumbing.ComputeHash(plumbing.BlobObject, data) != baseFile.Hash:
+			changes = append(changes, Change{Path: rel, Status: ChangeModified})
+		}
+	}
+	sortChanges(changes)
+	return changes, nil
+}
+
+func sortChanges(changes []Change) {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+}
+
+// Apply applies opts to the diff once it is open, such as those read from a
+// configuration file in it. Like the options given to OpenDiff, WithRef and
+// WithGitIndex have no effect.
+func (d *Diff) Apply(opts ...Option) {
+	r := &gitRepository{common: d.common}
+	for _, opt := range opts {
+		opt(r)
+	}
+}
+
+// GetFileContentContext returns the contents of filePath at head, or in the
+// working tree when head is empty, giving up once ctx is done.
+func (d *Diff) GetFileContentContext(ctx context.Context, filePath string) (string, error) {
+	return readContent(ctx, filePath, d.head)
+}
+
+// Changes returns the changed files in sorted path order, leaving out the
+// files of the sensitive files deny list.
+func (d *Diff) Changes() []Change {
+	var changes []Change
+	for _, c := range d.changes {
+		if _, sensitive := d.common.denied(c.Path); !sensitive {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// ConcatTo writes changes, usually a subset of Changes, to w in sorted path
+// order with the configured formatter, as the new contents of each file, a
+// unified diff of it, or both, depending on mode. Diffs are written as files
+// in the "Diff" language under the path of the changed file, with how it
+// changed as their note. Like Repository.ConcatTo, it refuses to write the
+// files of the sensitive files deny list.
+func (d *Diff) ConcatTo(ctx context.Context, w io.Writer, changes []Change, mode DiffMode) error {
+	rc := d.common
+	changes = slices.Clone(changes)
+	sortChanges(changes)
+	for _, c := range changes {
+		if pattern, sensitive := rc.denied(c.Path); sensitive {
+			return &SensitiveFileError{Path: c.Path, Pattern: pattern}
+		}
+	}
+	if err := rc.formatter.Begin(w); err != nil {
+		return err
+	}
+	index := 0
+	var used int64
+	for _, c := range changes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if mode != DiffPatch {
+			if c.Status == ChangeDeleted {
+				file := File{Path: c.Path, Language: rc.getLanguage(c.Path), Content: strings.NewReader(""), Note: string(ChangeDeleted)}
+				if err := rc.formatter.WriteFile(w, index, file); err != nil {
+					return err
+				}
+				index++
+			} else {
+				written, n, err := rc.writeFile(ctx, w, index, c.Path, used, d.head)
+				if err != nil {
+					return err
+				}
+				if written {
+					index++
+					used += n
+				}
+			}
+		}
+		if mode != DiffContents {
+			n, err := d.writePatch(w, index, c, used)
+			if err != nil {
+				return err
+			}
+			index++
+			used += n
+		}
+	}
+	return rc.formatter.End(w)
+}
+
+// ConcatFiles renders changes like ConcatTo into a string.
+func (d *Diff) ConcatFiles(changes []Change, mode DiffMode) (string, error) {
+	var sb strings.Builder
+	if err := d.ConcatTo(context.Background(), &sb, changes, mode); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// writePatch writes the unified diff of c as the entry at index when used
+// bytes of contents have already been written, returning the number of
+// bytes of the diff written.
+func (d *Diff) writePatch(w io.Writer, index int, c Change, used int64) (int64, error) {
+	var from, to []byte
+	var err error
+	if c.Status != ChangeAdded {
+		if from, err = readAll(d.base, c.Path); err != nil {
+			return 0, err
+		}
+	}
+	if c.Status != ChangeDeleted {
+		if to, err = readAll(d.head, c.Path); err != nil {
+			return 0, err
+		}
+	}
+	patch, err := unifiedDiff(filepath.ToSlash(c.Path), from, to, c.Status)
+	if err != nil {
+		return 0, err
+	}
+	patch = d.common.redactText(c.Path, patch)
+
+	file := File{
+		Path:     c.Path,
+		Language: "Diff",
+		Size:     int64(len(patch)),
+		Content:  strings.NewReader(patch),
+	}
+	n, err := d.common.limitFile(&file, file.Size, used, false)
+	if err != nil {
+		return 0, err
+	}
+	file.Note = strings.Join(nonEmpty(string(c.Status), file.Note), "; ")
+	return n, d.common.formatter.WriteFile(w, index, file)
+}
+
+func readAll(open openFunc, filePath string) ([]byte, error) {
+	reader, _, err := open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// unifiedDiff returns the diff turning from into to in the format of "git
+// diff", with three lines of context. from is ignored for added files and
+// to for deleted ones.
+func unifiedDiff(filePath string, from, to []byte, status ChangeStatus) (string, error) {
+	fp := &filePatch{binary: IsBinary(from) || IsBinary(to)}
+	if status != ChangeAdded {
+		fp.from = &patchFile{path: filePath, hash: plumbing.ComputeHash(plumbing.BlobObject, from)}
+	}
+	if status != ChangeDeleted {
+		fp.to = &patchFile{path: filePath, hash: plumbing.ComputeHash(plumbing.BlobObject, to)}
+	}
+	if !fp.binary {
+		for _, d := range diff.Do(string(from), string(to)) {
+			op := fdiff.Equal
+			switch d.Type {
+			case dmp.DiffDelete:
+				op = fdiff.Delete
+			case dmp.DiffInsert:
+				op = fdiff.Add
+			}
+			fp.chunks = append(fp.chunks, patchChunk{content: d.Text, op: op})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := fdiff.NewUnifiedEncoder(&buf, fdiff.DefaultContextLines).Encode(patch{fp}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// patch, filePatch, patchFile and patchChunk implement the interfaces of
+// go-git's unified diff encoder for contents that are not all Git objects.
+type patch []fdiff.FilePatch
+
+func (p patch) FilePatches() []fdiff.FilePatch { return p }
+func (p patch) Message() string                { return "" }
+
+type filePatch struct {
+	from, to *patchFile
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func (p *filePatch) IsBinary() bool        { return p.binary }
+func (p *filePatch) Chunks() []fdiff.Chunk { return p.chunks }
+
+// Files returns nil interfaces, not nil pointers, for a missing side, as the
+// encoder expects.
+func (p *filePatch) Files() (from, to fdiff.File) {
+	if p.from != nil {
+		from = p.from
+	}
+	if p.to != nil {
+		to = p.to
+	}
+	return from, to
+}
+
+type patchFile struct {
+	path string
+	hash plumbing.Hash
+}
+
+func (f *patchFile) Hash() plumbing.Hash     { return f.hash }
+func (f *patchFile) Mode() filemode.FileMode { return filemode.Regular }
+func (f *patchFile) Path() string            { return f.path }
+
+type patchChunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c patchChunk) Content() string       { return c.content }
+func (c patchChunk) Type() fdiff.Operation { return c.op }
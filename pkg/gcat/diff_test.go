@@ -0,0 +1,230 @@
+package gcat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// newDiffWorktree creates a Git working tree with two commits and staged,
+// unstaged and untracked changes on top of them.
+func newDiffWorktree(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	commit := func(message string, when int64) {
+		_, err := wt.Add(".")
+		require.NoError(t, err)
+		_, err = wt.Commit(message, &git.CommitOptions{
+			All:    true,
+			Author: &object.Signature{Name: "gcat", Email: "gcat@example.com", When: time.Unix(when, 0)},
+		})
+		require.NoError(t, err)
+	}
+
+	write("a.txt", "one\ntwo\nthree\n")
+	write("sub/b.go", "package sub\n")
+	write("c.txt", "removed later\n")
+	commit("initial", 1700000000)
+
+	write("a.txt", "one\n2\nthree\n")
+	require.NoError(t, os.Remove(filepath.Join(dir, "c.txt")))
+	write("sub/d.txt", "added\n")
+	commit("second", 1700000100)
+
+	write("sub/b.go", "package sub\n\nfunc B() {}\n")
+	write("e.txt", "staged\n")
+	_, err = wt.Add("e.txt")
+	require.NoError(t, err)
+	write("untracked.txt", "not part of the diff\n")
+	return dir
+}
+
+func TestParseRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec, base, head string
+		wantErr          bool
+	}{
+		{spec: "main..feature", base: "main", head: "feature"},
+		{spec: "main..", base: "main"},
+		{spec: "main", base: "main"},
+		{spec: "..feature", base: "HEAD", head: "feature"},
+		{spec: "", base: "HEAD"},
+		{spec: "main...feature", wantErr: true},
+	}
+	for _, tt := range tests {
+		base, head, err := ParseRange(tt.spec)
+		if tt.wantErr {
+			assert.Error(t, err, tt.spec)
+			continue
+		}
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.base, base, tt.spec)
+		assert.Equal(t, tt.head, head, tt.spec)
+	}
+}
+
+func TestOpenDiff_Changes(t *testing.T) {
+	t.Parallel()
+
+	dir := newDiffWorktree(t)
+
+	tests := []struct {
+		name       string
+		root       string
+		base, head string
+		want       []Change
+	}{
+		{
+			name: "between commits",
+			root: dir,
+			base: "HEAD~1",
+			head: "HEAD",
+			want: []Change{
+				{Path: "a.txt", Status: ChangeModified},
+				{Path: "c.txt", Status: ChangeDeleted},
+				{Path: filepath.Join("sub", "d.txt"), Status: ChangeAdded},
+			},
+		},
+		{
+			name: "working tree against HEAD",
+			root: dir,
+			base: "HEAD",
+			want: []Change{
+				{Path: "e.txt", Status: ChangeAdded},
+				{Path: filepath.Join("sub", "b.go"), Status: ChangeModified},
+			},
+		},
+		{
+			name: "working tree against an older commit",
+			root: dir,
+			base: "HEAD~1",
+			want: []Change{
+				{Path: "a.txt", Status: ChangeModified},
+				{Path: "c.txt", Status: ChangeDeleted},
+				{Path: "e.txt", Status: ChangeAdded},
+				{Path: filepath.Join("sub", "b.go"), Status: ChangeModified},
+				{Path: filepath.Join("sub", "d.txt"), Status: ChangeAdded},
+			},
+		},
+		{
+			name: "subfolder",
+			root: filepath.Join(dir, "sub"),
+			base: "HEAD~1",
+			want: []Change{
+				{Path: "b.go", Status: ChangeModified},
+				{Path: "d.txt", Status: ChangeAdded},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := OpenDiff(tt.root, tt.base, tt.head)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, d.Changes())
+		})
+	}
+
+	_, err := OpenDiff(dir, "no-such-branch", "")
+	assert.Error(t, err)
+}
+
+func TestDiff_ConcatFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := newDiffWorktree(t)
+	d, err := OpenDiff(dir, "HEAD~1", "HEAD", WithFormatter(PlainFormatter{}))
+	require.NoError(t, err)
+	changes := d.Changes()
+
+	tests := []struct {
+		name string
+		mode DiffMode
+		want string
+	}{
+		{
+			name: "contents",
+			mode: DiffContents,
+			want: "==> a.txt <==\none\n2\nthree\n\n\n" +
+				"==> c.txt (deleted) <==\n\n\n" +
+				"==> " + filepath.Join("sub", "d.txt") + " <==\nadded\n",
+		},
+		{
+			name: "patch",
+			mode: DiffPatch,
+			want: "==> a.txt (modified) <==\n" +
+				"diff --git a/a.txt b/a.txt\n" +
+				"index 4cb29ea38f70d7c61b2a3a25b02e3bdf44905402..f04eb265ebd74fba2cddf0a6adf2a6a7f81c87aa 100644\n" +
+				"--- a/a.txt\n" +
+				"+++ b/a.txt\n" +
+				"@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n\n\n" +
+				"==> c.txt (deleted) <==\n" +
+				"diff --git a/c.txt b/c.txt\n" +
+				"deleted file mode 100644\n" +
+				"index 9d43c40ca6d59a45986ef8687c1492adacfc80aa..0000000000000000000000000000000000000000\n" +
+				"--- a/c.txt\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n-removed later\n\n\n" +
+				"==> " + filepath.Join("sub", "d.txt") + " (added) <==\n" +
+				"diff --git a/sub/d.txt b/sub/d.txt\n" +
+				"new file mode 100644\n" +
+				"index 0000000000000000000000000000000000000000..d5f7fc3f74f7dec08280f370a975b112e8f60818\n" +
+				"--- /dev/null\n" +
+				"+++ b/sub/d.txt\n" +
+				"@@ -0,0 +1 @@\n+added\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := d.ConcatFiles(changes, tt.mode)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("both", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := d.ConcatFiles(changes[:1], DiffBoth)
+		require.NoError(t, err)
+		assert.Equal(t, "==> a.txt <==\none\n2\nthree\n\n\n==> a.txt (modified) <==\ndiff --git a/a.txt b/a.txt\n"+
+			"index 4cb29ea38f70d7c61b2a3a25b02e3bdf44905402..f04eb265ebd74fba2cddf0a6adf2a6a7f81c87aa 100644\n"+
+			"--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n", got)
+	})
+}
+
+func TestDiff_WorkingTreePatch(t *testing.T) {
+	t.Parallel()
+
+	dir := newDiffWorktree(t)
+	d, err := OpenDiff(filepath.Join(dir, "sub"), "HEAD", "", WithFormatter(JSONLFormatter{}))
+	require.NoError(t, err)
+	got, err := d.ConcatFiles(d.Changes(), DiffPatch)
+	require.NoError(t, err)
+	assert.Contains(t, got, `"language":"Diff"`)
+	assert.Contains(t, got, `+func B() {}`)
+}
@@ -0,0 +1,391 @@
+package gcat
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/timsexperiments/gcat/pkg/lang"
+)
+
+// File is a single file handed to a Formatter.
+//
+// Content streams the file's bytes and can only be read once. Size is the
+// size of the file itself; for binary files rendered as text, Encoding names
+// the encoding of Content (see BinaryPolicy). Note explains why Content is
+// incomplete, such as when a size limit truncated or skipped the file, and
+// formatters include it in the file's header.
+type File struct {
+	Path     string
+	Language string
+	Size     int64
+	Encoding string
+	Note     string
+	Content  io.Reader
+}
+
+// Formatter renders the files produced by ConcatFiles and ConcatTo.
+//
+// Begin is called once before the first file, WriteFile once per file in
+// output order (index is zero-based) and End once after the last file.
+type Formatter interface {
+	Begin(w io.Writer) error
+	WriteFile(w io.Writer, index int, file File) error
+	End(w io.Writer) error
+}
+
+// SectionWriter is implemented by formatters that render sections that are
+// not files, such as the directory tree written with WithTree, differently
+// from files. Sections share the index sequence of the files. Formatters
+// without it get each section as a File whose Path is the title.
+type SectionWriter interface {
+	WriteSection(w io.Writer, index int, title, content string) error
+}
+
+// writeSection writes a section as the entry at index.
+func (rc *repoCommon) writeSection(w io.Writer, index int, title, content string) error {
+	if sw, ok := rc.formatter.(SectionWriter); ok {
+		return sw.WriteSection(w, index, title, content)
+	}
+	return rc.formatter.WriteFile(w, index, File{Path: title, Size: int64(len(content)), Content: strings.NewReader(content)})
+}
+
+// Names of the built-in formatters accepted by FormatterByName.
+const (
+	FormatDefault  = "default"
+	FormatMarkdown = "markdown"
+	FormatXML      = "xml"
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl"
+	FormatPlain    = "plain"
+)
+
+var formatters = map[string]Formatter{
+	FormatDefault:  DefaultFormatter{},
+	FormatMarkdown: MarkdownFormatter{},
+	FormatXML:      XMLFormatter{},
+	FormatJSON:     JSONFormatter{},
+	FormatJSONL:    JSONLFormatter{},
+	FormatPlain:    PlainFormatter{},
+}
+
+// FormatterByName returns the built-in formatter registered under name.
+func FormatterByName(name string) (Formatter, error) {
+	if f, ok := formatters[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (available: %s)", name, strings.Join(FormatNames(), ", "))
+}
+
+// FormatNames returns the names of the built-in formatters in sorted order.
+func FormatNames() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultFormatter writes each file as "path (Language):" followed by its
+// contents wrapped in <contents> tags, separating files with "---".
+type DefaultFormatter struct{}
+
+func (DefaultFormatter) Begin(w io.Writer) error { return nil }
+
+func (DefaultFormatter) WriteFile(w io.Writer, index int, file File) error {
+	if index > 0 {
+		if _, err := io.WriteString(w, "\n\n---\n\n"); err != nil {
+			return err
+		}
+	}
+	header := file.Path
+	if details := nonEmpty(file.Language, file.Encoding, file.Note); len(details) > 0 {
+		header = fmt.Sprintf("%s (%s)", file.Path, strings.Join(details, ", "))
+	}
+	if _, err := fmt.Fprintf(w, "%s:\n\n<contents>\n", header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, file.Content); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n</contents>")
+	return err
+}
+
+func (DefaultFormatter) End(w io.Writer) error { return nil }
+
+// nonEmpty returns the values that are not empty.
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// MarkdownFormatter writes each file as a heading followed by a fenced code
+// block tagged with the file's language, such as "tsx" or "cpp".
+//
+// Each file is buffered in memory so the fence can be made longer than any run
+// of backticks inside it.
+type MarkdownFormatter struct{}
+
+func (MarkdownFormatter) Begin(w io.Writer) error { return nil }
+
+func (MarkdownFormatter) WriteFile(w io.Writer, index int, file File) error {
+	if index > 0 {
+		if _, err := io.WriteString(w, "\n\n"); err != nil {
+			return err
+		}
+	}
+	data, err := io.ReadAll(file.Content)
+	if err != nil {
+		return err
+	}
+	content := string(data)
+	fence := strings.Repeat("`", max(3, longestRun(content, '`')+1))
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	heading := file.Path
+	if details := nonEmpty(file.Encoding, file.Note); len(details) > 0 {
+		heading = fmt.Sprintf("%s (%s)", file.Path, strings.Join(details, ", "))
+	}
+	_, err = fmt.Fprintf(w, "## %s\n\n%s%s\n%s%s", heading, fence, fenceTag(file), content, fence)
+	return err
+}
+
+// WriteSection writes a section as a heading followed by a "text" code block.
+func (f MarkdownFormatter) WriteSection(w io.Writer, index int, title, content string) error {
+	return f.WriteFile(w, index, File{Path: title, Language: "Text", Content: strings.NewReader(content)})
+}
+
+func (MarkdownFormatter) End(w io.Writer) error { return nil }
+
+// fenceTag returns the info string used for a Markdown code fence: "text" for
+// encoded binary files, the fence identifier of the file's language, or its
+// extension if the language is not in the lang package.
+func fenceTag(file File) string {
+	if file.Encoding != "" {
+		return "text"
+	}
+	if l, ok := lang.Lookup(file.Language); ok {
+		return l.Fence
+	}
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(file.Path), "."))
+}
+
+// longestRun returns the length of the longest run of c in s.
+func longestRun(s string, c byte) int {
+	longest, current := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != c {
+			current = 0
+			continue
+		}
+		current++
+		longest = max(longest, current)
+	}
+	return longest
+}
+
+// XMLFormatter writes the files as <document> blocks inside a <documents>
+// element, the layout recommended for long-context prompts to Claude.
+type XMLFormatter struct{}
+
+func (XMLFormatter) Begin(w io.Writer) error {
+	_, err := io.WriteString(w, "<documents>\n")
+	return err
+}
+
+func (XMLFormatter) WriteFile(w io.Writer, index int, file File) error {
+	var source strings.Builder
+	if err := xml.EscapeText(&source, []byte(file.Path)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "<document index=\"%d\">\n<source>%s</source>\n", index+1, source.String()); err != nil {
+		return err
+	}
+	if file.Encoding != "" {
+		var encoding strings.Builder
+		if err := xml.EscapeText(&encoding, []byte(file.Encoding)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "<encoding>%s</encoding>\n", encoding.String()); err != nil {
+			return err
+		}
+	}
+	if file.Note != "" {
+		var note strings.Builder
+		if err := xml.EscapeText(&note, []byte(file.Note)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "<note>%s</note>\n", note.String()); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, "<document_content>\n"); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, file.Content); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n</document_content>\n</document>\n")
+	return err
+}
+
+func (XMLFormatter) End(w io.Writer) error {
+	_, err := io.WriteString(w, "</documents>")
+	return err
+}
+
+// JSONFormatter writes the files as a JSON array of objects with path,
+// language, size and content fields. Each file is buffered in memory while it
+// is encoded.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Begin(w io.Writer) error {
+	_, err := io.WriteString(w, "[")
+	return err
+}
+
+func (JSONFormatter) WriteFile(w io.Writer, index int, file File) error {
+	sep := "\n"
+	if index > 0 {
+		sep = ",\n"
+	}
+	if _, err := io.WriteString(w, sep); err != nil {
+		return err
+	}
+	return writeJSON(w, file)
+}
+
+// WriteSection writes a section as an object with section and content
+// fields.
+func (JSONFormatter) WriteSection(w io.Writer, index int, title, content string) error {
+	sep := "\n"
+	if index > 0 {
+		sep = ",\n"
+	}
+	if _, err := io.WriteString(w, sep); err != nil {
+		return err
+	}
+	return writeJSONSection(w, title, content)
+}
+
+func (JSONFormatter) End(w io.Writer) error {
+	_, err := io.WriteString(w, "\n]")
+	return err
+}
+
+// jsonFile is the JSON representation of a File.
+type jsonFile struct {
+	Path     string `json:"path"`
+	Language string `json:"language"`
+	Size     int64  `json:"size"`
+	Encoding string `json:"encoding,omitempty"`
+	Note     string `json:"note,omitempty"`
+	Content  string `json:"content"`
+}
+
+// writeJSON writes file as a single line of JSON. HTML characters are left
+// unescaped since source code is full of them.
+func writeJSON(w io.Writer, file File) error {
+	content, err := io.ReadAll(file.Content)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err = enc.Encode(jsonFile{
+		Path:     file.Path,
+		Language: file.Language,
+		Size:     file.Size,
+		Encoding: file.Encoding,
+		Note:     file.Note,
+		Content:  string(content),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
+
+// writeJSONSection writes a section as a single line of JSON.
+func writeJSONSection(w io.Writer, title, content string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(struct {
+		Section string `json:"section"`
+		Content string `json:"content"`
+	}{title, content})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
+
+// JSONLFormatter writes one JSON object per line with path, language, size and
+// content fields. Each file is buffered in memory while it is encoded.
+type JSONLFormatter struct{}
+
+func (JSONLFormatter) Begin(w io.Writer) error { return nil }
+
+func (JSONLFormatter) WriteFile(w io.Writer, index int, file File) error {
+	if index > 0 {
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return writeJSON(w, file)
+}
+
+// WriteSection writes a section as an object with section and content
+// fields on its own line.
+func (JSONLFormatter) WriteSection(w io.Writer, index int, title, content string) error {
+	if index > 0 {
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return writeJSONSection(w, title, content)
+}
+
+func (JSONLFormatter) End(w io.Writer) error { return nil }
+
+// PlainFormatter writes each file's contents under a "==> path <==" banner,
+// the same layout head(1) and tail(1) use for multiple files.
+type PlainFormatter struct{}
+
+func (PlainFormatter) Begin(w io.Writer) error { return nil }
+
+func (PlainFormatter) WriteFile(w io.Writer, index int, file File) error {
+	if index > 0 {
+		if _, err := io.WriteString(w, "\n\n"); err != nil {
+			return err
+		}
+	}
+	banner := file.Path
+	if details := nonEmpty(file.Encoding, file.Note); len(details) > 0 {
+		banner = fmt.Sprintf("%s (%s)", file.Path, strings.Join(details, ", "))
+	}
+	if _, err := fmt.Fprintf(w, "==> %s <==\n", banner); err != nil {
+		return err
+	}
+	_, err := io.Copy(w, file.Content)
+	return err
+}
+
+func (PlainFormatter) End(w io.Writer) error { return nil }
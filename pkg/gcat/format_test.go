@@ -0,0 +1,198 @@
+package gcat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatters(t *testing.T) {
+	t.Parallel()
+
+	files := func() []File {
+		return []File{
+			{Path: "main.go", Language: "Go", Size: 13, Content: strings.NewReader("package main\n")},
+			{Path: "a&b.txt", Size: 2, Content: strings.NewReader("hi")},
+		}
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			name: FormatDefault,
+			want: "main.go (Go):\n\n<contents>\npackage main\n\n</contents>\n\n---\n\na&b.txt:\n\n<contents>\nhi\n</contents>",
+		},
+		{
+			name: FormatMarkdown,
+			want: "## main.go\n\n```go\npackage main\n```\n\n## a&b.txt\n\n```txt\nhi\n```",
+		},
+		{
+			name: FormatXML,
+			want: "<documents>\n" +
+				"<document index=\"1\">\n<source>main.go</source>\n<document_content>\npackage main\n\n</document_content>\n</document>\n" +
+				"<document index=\"2\">\n<source>a&amp;b.txt</source>\n<document_content>\nhi\n</document_content>\n</document>\n" +
+				"</documents>",
+		},
+		{
+			name: FormatJSON,
+			want: "[\n" +
+				`{"path":"main.go","language":"Go","size":13,"content":"package main\n"},` + "\n" +
+				`{"path":"a&b.txt","language":"","size":2,"content":"hi"}` + "\n]",
+		},
+		{
+			name: FormatJSONL,
+			want: `{"path":"main.go","language":"Go","size":13,"content":"package main\n"}` + "\n" +
+				`{"path":"a&b.txt","language":"","size":2,"content":"hi"}`,
+		},
+		{
+			name: FormatPlain,
+			want: "==> main.go <==\npackage main\n\n\n==> a&b.txt <==\nhi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := FormatterByName(tt.name)
+			require.NoError(t, err)
+
+			var sb strings.Builder
+			require.NoError(t, f.Begin(&sb))
+			for i, file := range files() {
+				require.NoError(t, f.WriteFile(&sb, i, file))
+			}
+			require.NoError(t, f.End(&sb))
+
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+}
+
+func TestFormatters_Note(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: FormatDefault, want: "a.go (Go, truncated: 2 of 5 bytes shown):\n\n<contents>\nab\n</contents>"},
+		{name: FormatMarkdown, want: "## a.go (truncated: 2 of 5 bytes shown)\n\n```go\nab\n```"},
+		{name: FormatXML, want: "<document index=\"1\">\n<source>a.go</source>\n<note>truncated: 2 of 5 bytes shown</note>\n<document_content>\nab\n</document_content>\n</document>\n"},
+		{name: FormatJSONL, want: `{"path":"a.go","language":"Go","size":5,"note":"truncated: 2 of 5 bytes shown","content":"ab"}`},
+		{name: FormatPlain, want: "==> a.go (truncated: 2 of 5 bytes shown) <==\nab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := FormatterByName(tt.name)
+			require.NoError(t, err)
+
+			var sb strings.Builder
+			file := File{Path: "a.go", Language: "Go", Size: 5, Note: "truncated: 2 of 5 bytes shown", Content: strings.NewReader("ab")}
+			require.NoError(t, f.WriteFile(&sb, 0, file))
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+}
+
+func TestFormatters_Encoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: FormatDefault, want: "logo.png (PNG, base64, truncated: 4 of 9 bytes shown):\n\n<contents>\niVBO\n</contents>"},
+		{name: FormatMarkdown, want: "## logo.png (base64, truncated: 4 of 9 bytes shown)\n\n```text\niVBO\n```"},
+		{name: FormatXML, want: "<document index=\"1\">\n<source>logo.png</source>\n<encoding>base64</encoding>\n<note>truncated: 4 of 9 bytes shown</note>\n<document_content>\niVBO\n</document_content>\n</document>\n"},
+		{name: FormatJSONL, want: `{"path":"logo.png","language":"PNG","size":9,"encoding":"base64","note":"truncated: 4 of 9 bytes shown","content":"iVBO"}`},
+		{name: FormatPlain, want: "==> logo.png (base64, truncated: 4 of 9 bytes shown) <==\niVBO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := FormatterByName(tt.name)
+			require.NoError(t, err)
+
+			var sb strings.Builder
+			file := File{Path: "logo.png", Language: "PNG", Size: 9, Encoding: "base64", Note: "truncated: 4 of 9 bytes shown", Content: strings.NewReader("iVBO")}
+			require.NoError(t, f.WriteFile(&sb, 0, file))
+			assert.Equal(t, tt.want, sb.String())
+		})
+	}
+}
+
+func TestJSONFormatter_Valid(t *testing.T) {
+	t.Parallel()
+
+	repo, err := NewLocalRepository("testdata/no-ignore", WithFormatter(JSONFormatter{}))
+	require.NoError(t, err)
+
+	output, err := repo.ConcatFiles([]string{"file2.txt", "file1.txt"})
+	require.NoError(t, err)
+
+	var got []jsonFile
+	require.NoError(t, json.Unmarshal([]byte(output), &got))
+	assert.Equal(t, []jsonFile{
+		{Path: "file1.txt", Language: "Text", Size: 12, Content: "test content"},
+		{Path: "file2.txt", Language: "Text", Size: 0, Content: ""},
+	}, got)
+}
+
+func TestMarkdownFormatter_Fence(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	err := MarkdownFormatter{}.WriteFile(&sb, 0, File{Path: "README.md", Content: strings.NewReader("```go\nx\n```")})
+	require.NoError(t, err)
+
+	assert.Equal(t, "## README.md\n\n````md\n```go\nx\n```\n````", sb.String())
+}
+
+func TestMarkdownFormatter_FenceTag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file File
+		want string
+	}{
+		{file: File{Path: "App.tsx", Language: "TSX"}, want: "```tsx\n"},
+		{file: File{Path: "lib.h", Language: "C++"}, want: "```cpp\n"},
+		{file: File{Path: "Makefile", Language: "Makefile"}, want: "```makefile\n"},
+		{file: File{Path: "notes.custom", Language: "Custom Notes"}, want: "```custom\n"},
+		{file: File{Path: "LICENSE"}, want: "```\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file.Path, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			tt.file.Content = strings.NewReader("x")
+			require.NoError(t, MarkdownFormatter{}.WriteFile(&sb, 0, tt.file))
+			assert.Contains(t, sb.String(), tt.want)
+		})
+	}
+}
+
+func TestFormatterByName(t *testing.T) {
+	t.Parallel()
+
+	f, err := FormatterByName("Markdown")
+	require.NoError(t, err)
+	assert.Equal(t, MarkdownFormatter{}, f)
+
+	_, err = FormatterByName("yaml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown format "yaml"`)
+}
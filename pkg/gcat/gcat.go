@@ -2,156 +2,90 @@
 package gcat
 
 import (
-	"path/filepath"
+	"context"
+	"io"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
+
+	"github.com/timsexperiments/gcat/pkg/lang"
+	"github.com/timsexperiments/gcat/pkg/redact"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
 	// go-git for git operations
 	// Git objects
 	// in-memory storage
 )
 
-// defaultLanguageMap maps file extensions to their corresponding languages or file types.
-var defaultLanguageMap = map[string]string{
-	// Programming Languages
-	".go":     "Go",
-	".py":     "Python",
-	".js":     "JavaScript",
-	".jsx":    "JavaScript (React)",
-	".ts":     "TypeScript",
-	".tsx":    "TypeScript (React)",
-	".java":   "Java",
-	".c":      "C",
-	".cpp":    "C++",
-	".cs":     "C#",
-	".rb":     "Ruby",
-	".rs":     "Rust",
-	".zig":    "Zig",
-	".gleam":  "Gleam",
-	".ex":     "Elixir",
-	".exs":    "Elixir",
-	".erl":    "Erlang",
-	".hrl":    "Erlang",
-	".v":      "V",
-	".mojo":   "Mojo",
-	".lua":    "Lua",
-	".r":      "R",
-	".jl":     "Julia",
-	".pl":     "Perl",
-	".pm":     "Perl",
-	".php":    "PHP",
-	".swift":  "Swift",
-	".kt":     "Kotlin",
-	".kts":    "Kotlin Script",
-	".ktm":    "Kotlin",
-	".dart":   "Dart",
-	".scala":  "Scala",
-	".groovy": "Groovy",
-	".hs":     "Haskell",
-	".elm":    "Elm",
-	".clj":    "Clojure",
-	".cljs":   "ClojureScript",
-	".cljc":   "Clojure",
-	".edn":    "EDN",
-	".rkt":    "Racket",
-	".scm":    "Scheme",
-	".ss":     "Scheme",
-	".lisp":   "Lisp",
-	".cl":     "Common Lisp",
-	".el":     "Emacs Lisp",
-	".ml":     "OCaml",
-	".mli":    "OCaml Interface",
-	".f90":    "Fortran",
-	".f95":    "Fortran",
-	".nim":    "Nim",
-	".j":      "J (array programming)",
-	".sas":    "SAS",
-	".sml":    "Standard ML",
-	".fs":     "F#",
-	".vb":     "Visual Basic",
-	".asm":    "Assembly",
-	".s":      "Assembly",
-	".nasm":   "Assembly",
-	".vhd":    "VHDL",
-	".vhdl":   "VHDL",
-
-	// Markup / Web
-	".html":       "HTML",
-	".htm":        "HTML",
-	".css":        "CSS",
-	".scss":       "SCSS",
-	".sass":       "Sass",
-	".less":       "Less",
-	".xml":        "XML",
-	".xsl":        "XSLT",
-	".svg":        "SVG",
-	".md":         "Markdown",
-	".rst":        "reStructuredText",
-	".adoc":       "AsciiDoc",
-	".jade":       "Jade/Pug",
-	".pug":        "Pug",
-	".ejs":        "EJS",
-	".handlebars": "Handlebars",
-	".hbs":        "Handlebars",
-
-	// Configuration & Data Formats
-	".json":  "JSON",
-	".yaml":  "YAML",
-	".yml":   "YAML",
-	".toml":  "TOML",
-	".ini":   "INI",
-	".conf":  "Configuration",
-	".cfg":   "Configuration",
-	".plst":  "Property List",
-	".plist": "Property List",
-	".csv":   "CSV",
-	".tsv":   "TSV",
-	".env":   "Environment Variables",
-
-	// Scripting and Command Languages
-	".sh":   "Shell Script",
-	".bash": "Bash",
-	".zsh":  "Zsh",
-	".fish": "Fish",
-	".ps1":  "PowerShell",
-	".bat":  "Batch",
-	".cmd":  "Batch",
-	".sql":  "SQL",
-
-	// Markup Languages for Documents
-	".tex": "LaTeX",
-	".ltx": "LaTeX",
-	".bib": "BibTeX",
-
-	// Miscellaneous / Other
-	".log":        "Log File",
-	".mdown":      "Markdown",
-	".markdown":   "Markdown",
-	".lock":       "Lock File",
-	".dockerfile": "Dockerfile",
-	".makefile":   "Makefile",
-	".txt":        "Text",
-	".text":       "Text",
-	".rtf":        "Rich Text Format",
-	".pdf":        "PDF",
-}
-
 // Repository defines the interface for obtaining file listings and contents.
+//
+// The methods taking a context stop with the context's error once it is done;
+// the others use context.Background.
 type Repository interface {
 	GetFiles() ([]string, error)
+	GetFilesContext(ctx context.Context) ([]string, error)
 	GetFileContent(filePath string) (string, error)
+	GetFileContentContext(ctx context.Context, filePath string) (string, error)
 	ConcatFiles(files []string) (string, error)
+	ConcatFilesContext(ctx context.Context, files []string) (string, error)
+	// ConcatTo streams the formatted files to w in sorted order, reading one
+	// file at a time. If an error occurs, w may have received partial output.
+	ConcatTo(ctx context.Context, w io.Writer, files []string) error
 	GetLanguage(filePath string) string
+	// FileSize returns the size of a file in bytes without reading it.
+	FileSize(filePath string) (int64, error)
 }
 
+// openFunc opens a file of a repository for reading and reports its size.
+type openFunc func(filePath string) (io.ReadCloser, int64, error)
+
 type repoCommon struct {
 	languages map[string]string
+	formatter Formatter
+	binary    BinaryPolicy
+
+	maxFileSize  int64
+	maxTotalSize int64
+	oversize     OversizePolicy
+
+	tree *TreeOptions
+	// getFiles lists the files of the repository for the directory tree.
+	getFiles func(ctx context.Context) ([]string, error)
+
+	outline     bool
+	summarizers map[string]Summarizer
+
+	redactor *redact.Redactor
+
+	// concurrency is the number of files read and processed at once.
+	concurrency int
+
+	sensitive      []string
+	allowSensitive bool
+
+	history *HistoryOptions
+	// locateHistory finds the Git repository, commit and repository path
+	// the history of a file is read from.
+	locateHistory func(filePath string) (*git.Repository, plumbing.Hash, string, error)
 }
 
 func newRepoCommon() *repoCommon {
 	rc := &repoCommon{
 		languages: make(map[string]string),
+		formatter: DefaultFormatter{},
+		binary:    BinarySkip,
+		oversize:  OversizeTruncate,
+		sensitive: DefaultSensitivePatterns(),
+
+		concurrency: runtime.GOMAXPROCS(0),
 	}
-	for ext, lang := range defaultLanguageMap {
-		rc.languages[ext] = lang
+	for _, l := range lang.All() {
+		for _, ext := range l.Extensions {
+			rc.languages[ext] = primaryLanguage(ext)
+		}
 	}
 	return rc
 }
@@ -160,12 +94,241 @@ func (rc *repoCommon) registerLanguage(ext, languageName string) {
 	rc.languages[ext] = languageName
 }
 
+// getLanguage identifies the language of filePath from its name alone.
 func (rc *repoCommon) getLanguage(filePath string) string {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	if lang, ok := rc.languages[ext]; ok {
-		return lang
+	return rc.detectLanguage(filePath, nil)
+}
+
+// languageOf identifies the language of filePath from its name and contents.
+func (rc *repoCommon) languageOf(filePath string, open openFunc) string {
+	return rc.detectLanguage(filePath, readHead(filePath, open))
+}
+
+// concatFiles renders files with the configured formatter into a string.
+func (rc *repoCommon) concatFiles(ctx context.Context, files []string, open openFunc) (string, error) {
+	var sb strings.Builder
+	if err := rc.concatTo(ctx, &sb, files, open); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// concatTo renders files in sorted order with the configured formatter,
+// streaming each file from open straight to w. It writes nothing if any of
+// files is on the sensitive files deny list.
+func (rc *repoCommon) concatTo(ctx context.Context, w io.Writer, files []string, open openFunc) error {
+	files = slices.Clone(files)
+	sort.Strings(files)
+	if err := rc.checkSensitive(files); err != nil {
+		return err
+	}
+	if err := rc.begin(ctx, w, files); err != nil {
+		return err
+	}
+	err := rc.writeEntries(ctx, files, open, func(string) io.Writer { return w })
+	if err != nil {
+		return err
+	}
+	return rc.formatter.End(w)
+}
+
+// begin writes the start of the output and, with WithTree, the tree of
+// files.
+func (rc *repoCommon) begin(ctx context.Context, w io.Writer, files []string) error {
+	if err := rc.formatter.Begin(w); err != nil {
+		return err
+	}
+	if rc.tree != nil {
+		return rc.writeTree(ctx, w, 0, files)
+	}
+	return nil
+}
+
+// firstEntry returns the index of the first file's entry, which follows the
+// tree.
+func (rc *repoCommon) firstEntry() int {
+	if rc.tree != nil {
+		return 1
+	}
+	return 0
+}
+
+// writeEntries writes the entry of each of files, in order, followed by its
+// history, to the writer entry returns for the file.
+func (rc *repoCommon) writeEntries(ctx context.Context, files []string, open openFunc, entry func(filePath string) io.Writer) error {
+	next := rc.sequentialFiles(ctx, files, open)
+	if rc.concurrency > 1 && len(files) > 1 {
+		var stop context.CancelFunc
+		ctx, stop = context.WithCancel(ctx)
+		// Stops the workers when writing fails.
+		defer stop()
+		next = rc.parallelFiles(ctx, files, open)
+	}
+	var histories map[string][]*object.Commit
+	if rc.history != nil {
+		var err error
+		if histories, err = rc.fileHistories(files); err != nil {
+			return err
+		}
+	}
+	index := rc.firstEntry()
+	var used int64
+	for range files {
+		p, err := next()
+		if err != nil {
+			return err
+		}
+		w := entry(p.file.Path)
+		written, n, err := rc.writePrepared(w, index, p, used)
+		p.close()
+		if err != nil {
+			return err
+		}
+		if !written {
+			continue
+		}
+		index++
+		used += n
+		if rc.history != nil {
+			wrote, err := rc.writeHistory(w, index, p.file.Path, histories[p.file.Path])
+			if err != nil {
+				return err
+			}
+			if wrote {
+				index++
+			}
+		}
+	}
+	return nil
+}
+
+// preparedFile is a file read and processed up to the size limits, which
+// depend on the files written before it.
+type preparedFile struct {
+	file   File
+	size   int64
+	binary bool
+	notes  []string
+	// close releases the file the contents are read from.
+	close func() error
+}
+
+// prepareFile opens filePath, detects its language and, for text files,
+// applies the outline and redaction. The caller must call close on the
+// result.
+func (rc *repoCommon) prepareFile(ctx context.Context, filePath string, open openFunc) (*preparedFile, error) {
+	reader, size, err := open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	content, head, err := sniff(&contextReader{ctx: ctx, r: reader})
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	p := &preparedFile{
+		file: File{
+			Path:     filePath,
+			Language: rc.detectLanguage(filePath, head),
+			Size:     size,
+			Content:  content,
+		},
+		size:   size,
+		binary: IsBinary(head),
+		close:  reader.Close,
+	}
+	if p.binary {
+		return p, nil
+	}
+	var outlined bool
+	if p.size, outlined, err = rc.outlineFile(&p.file, p.size); err != nil {
+		p.close()
+		return nil, err
+	}
+	if outlined {
+		p.notes = append(p.notes, outlineNote)
+	}
+	var reopen func() (io.Reader, error)
+	if !outlined {
+		reopen = func() (io.Reader, error) {
+			p.close()
+			p.close = func() error { return nil }
+			reader, _, err := open(filePath)
+			if err != nil {
+				return nil, err
+			}
+			p.close = reader.Close
+			return &contextReader{ctx: ctx, r: reader}, nil
+		}
+	}
+	var redacted string
+	if p.size, redacted, err = rc.redactFile(&p.file, p.size, reopen); err != nil {
+		p.close()
+		return nil, err
 	}
-	return ""
+	p.notes = append(p.notes, redacted)
+	return p, nil
+}
+
+// writeFile renders a single file when used bytes of contents have already
+// been written. It reports false for binary files left out by the binary
+// policy, and otherwise the number of bytes of the file's contents written.
+func (rc *repoCommon) writeFile(ctx context.Context, w io.Writer, index int, filePath string, used int64, open openFunc) (bool, int64, error) {
+	p, err := rc.prepareFile(ctx, filePath, open)
+	if err != nil {
+		return false, 0, err
+	}
+	defer p.close()
+	return rc.writePrepared(w, index, p, used)
+}
+
+// writePrepared renders a prepared file like writeFile.
+func (rc *repoCommon) writePrepared(w io.Writer, index int, p *preparedFile, used int64) (bool, int64, error) {
+	file := p.file
+	if p.binary {
+		switch rc.binary {
+		case BinarySkip:
+			return false, 0, nil
+		case BinaryPlaceholder:
+			file.Content, _ = rc.renderBinary(file.Content, p.size)
+			return true, 0, rc.formatter.WriteFile(w, index, file)
+		}
+	}
+	n, err := rc.limitFile(&file, p.size, used, p.binary)
+	if err != nil {
+		return false, 0, err
+	}
+	file.Note = strings.Join(nonEmpty(append(p.notes, file.Note)...), "; ")
+	if p.binary && n > 0 {
+		rendered, encoding := rc.renderBinary(file.Content, p.size)
+		defer rendered.Close()
+		file.Content, file.Encoding = rendered, encoding
+	}
+	return true, n, rc.formatter.WriteFile(w, index, file)
+}
+
+// contextReader stops reading once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// commonOf returns the shared settings of the built-in repository types.
+func commonOf(r Repository) *repoCommon {
+	switch repo := r.(type) {
+	case *localRepository:
+		return repo.common
+	case *gitRepository:
+		return repo.common
+	}
+	return nil
 }
 
 // Option is a functional option to modify repository settings.
@@ -173,30 +336,107 @@ type Option func(rc Repository)
 
 func WithRegisteredLanguages(langs map[string]string) Option {
 	return func(r Repository) {
-		var rc *repoCommon
-		if lr, ok := r.(*localRepository); ok {
-			rc = lr.common
+		if rc := commonOf(r); rc != nil {
+			for ext, name := range langs {
+				rc.registerLanguage(ext, name)
+			}
 		}
+	}
+}
+
+// WithFormatter sets the formatter used by ConcatFiles. The default is
+// DefaultFormatter.
+func WithFormatter(f Formatter) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil && f != nil {
+			rc.formatter = f
+		}
+	}
+}
 
+// WithBinaryPolicy sets how binary files are handled. With the default,
+// BinarySkip, binary files are hidden from GetFiles and left out of the
+// output; any other policy lists them and renders them as that policy
+// describes.
+func WithBinaryPolicy(policy BinaryPolicy) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil && policy != "" {
+			rc.binary = policy
+		}
+	}
+}
+
+// WithRef selects the branch, tag or commit SHA (full or abbreviated) a Git
+// repository is read at instead of the default branch. It has no effect on
+// local directories.
+func WithRef(ref string) Option {
+	return func(r Repository) {
 		if gr, ok := r.(*gitRepository); ok {
-			rc = gr.common
+			gr.ref = ref
 		}
+	}
+}
 
-		if rc != nil {
-			for ext, name := range langs {
-				rc.registerLanguage(ext, name)
-			}
+// WithAuth sets the credentials used to clone a Git repository. See
+// DefaultAuth for credentials discovered from the environment. It has no
+// effect on local directories.
+func WithAuth(auth transport.AuthMethod) Option {
+	return func(r Repository) {
+		if gr, ok := r.(*gitRepository); ok {
+			gr.auth = auth
+		}
+	}
+}
+
+// WithGitIndex makes a local directory inside a Git working tree list its
+// files from the Git index, matching "git ls-files", instead of walking the
+// filesystem. When includeUntracked is true, untracked files that are not
+// excluded by .gitignore, .git/info/exclude or the global excludes file are
+// listed too. It has no effect on remote repositories.
+func WithGitIndex(includeUntracked bool) Option {
+	return func(r Repository) {
+		if lr, ok := r.(*localRepository); ok {
+			lr.gitIndex = true
+			lr.untracked = includeUntracked
 		}
 	}
 }
 
 // OpenRepository returns a Repository from a given pathOrURL.
 //
-// If the input starts with "http://" or "https://", it is assumed to be a Git repository URL;
-// otherwise, it is assumed to be a local folder.
+// If IsRemoteURL reports the input as a Git remote (an HTTP(S), ssh:// or
+// git:// URL, or "git@host:org/repo.git"), it is cloned; otherwise, it is
+// assumed to be a local folder.
 func OpenRepository(pathOrURL string, opts ...Option) (Repository, error) {
-	if strings.HasPrefix(pathOrURL, "http://") || strings.HasPrefix(pathOrURL, "https://") {
-		return CloneGitRepository(pathOrURL, opts...)
+	return OpenRepositoryContext(context.Background(), pathOrURL, opts...)
+}
+
+// OpenRepositoryContext is OpenRepository, giving up cloning a remote
+// repository once ctx is done.
+func OpenRepositoryContext(ctx context.Context, pathOrURL string, opts ...Option) (Repository, error) {
+	if IsRemoteURL(pathOrURL) {
+		return CloneGitRepositoryContext(ctx, pathOrURL, opts...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 	return NewLocalRepository(pathOrURL, opts...)
 }
+
+// readContent reads the whole of filePath from open, stopping once ctx is
+// done.
+func readContent(ctx context.Context, filePath string, open openFunc) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	reader, _, err := open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(&contextReader{ctx: ctx, r: reader})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -32,7 +32,7 @@ func TestRepoCommon_GetLanguage(t *testing.T) {
 		},
 		{
 			filename: "component.jsx",
-			want:     "JavaScript (React)",
+			want:     "JavaScript",
 		},
 		{
 			filename: "module.ts",
@@ -40,7 +40,7 @@ func TestRepoCommon_GetLanguage(t *testing.T) {
 		},
 		{
 			filename: "module.tsx",
-			want:     "TypeScript (React)",
+			want:     "TSX",
 		},
 		{
 			filename: "Program.java",
@@ -95,7 +95,7 @@ func TestRepoCommon_GetLanguage(t *testing.T) {
 		// Scripting Languages
 		{
 			filename: "script.sh",
-			want:     "Shell Script",
+			want:     "Shell",
 		},
 		// Files without a known extension should return an empty string.
 		{
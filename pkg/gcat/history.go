@@ -0,0 +1,266 @@
+package gcat
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// HistoryOptions configure the commit history written after each file by
+// WithHistory.
+type HistoryOptions struct {
+	// Commits is the number of most recent commits listed per file.
+	Commits int
+	// Patch adds the changes each commit made to the file.
+	Patch bool
+}
+
+// WithHistory writes the most recent commits that touched each file after
+// its contents, as a section titled "History of <path>" listing the
+// abbreviated hash, author date, author and subject of each commit. Files of
+// local folders outside of a Git working tree, and files without commits,
+// have no history. Remote repositories are cloned with their full history
+// when it is set.
+//
+// The log is walked once for all the files written, diffing each commit with
+// its parents, and stops as soon as every file has its commits. Files with
+// fewer commits than opts.Commits, such as a file that was deleted and added
+// back, make it walk the whole history.
+func WithHistory(opts HistoryOptions) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil && opts.Commits > 0 {
+			rc.history = &opts
+		}
+	}
+}
+
+// fileLogs returns the last n commits reachable from the commit from, or
+// from HEAD if it is the zero hash, that touched each of repoPaths,
+// slash-separated paths from the root of repo. It walks the log once,
+// attributing a commit to the paths it changed compared to its first parent
+// and, for merges, to every other parent too, like git log does.
+func fileLogs(repo *git.Repository, from plumbing.Hash, repoPaths []string, n int) (map[string][]*object.Commit, error) {
+	logs := make(map[string][]*object.Commit, len(repoPaths))
+	wanted := make(map[string]bool, len(repoPaths))
+	for _, repoPath := range repoPaths {
+		wanted[repoPath] = true
+	}
+	iter, err := repo.Log(&git.LogOptions{From: from, Order: git.LogOrderCommitterTime})
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return logs, nil // no commits yet
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	for len(wanted) > 0 {
+		c, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		changed, err := changedPaths(c, wanted)
+		if err != nil {
+			return nil, err
+		}
+		for _, repoPath := range changed {
+			logs[repoPath] = append(logs[repoPath], c)
+			if len(logs[repoPath]) == n {
+				delete(wanted, repoPath)
+			}
+		}
+	}
+	return logs, nil
+}
+
+// changedPaths returns the paths of wanted that c changed compared to each
+// of its parents, or that it added if it has none.
+func changedPaths(c *object.Commit, wanted map[string]bool) ([]string, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for i := 0; i == 0 || i < c.NumParents(); i++ {
+		var parentTree *object.Tree
+		if c.NumParents() > 0 {
+			parent, err := c.Parent(i)
+			if err != nil {
+				return nil, err
+			}
+			if parentTree, err = parent.Tree(); err != nil {
+				return nil, err
+			}
+		}
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return nil, err
+		}
+		paths := map[string]bool{}
+		for _, change := range changes {
+			for _, name := range []string{change.From.Name, change.To.Name} {
+				if wanted[name] {
+					paths[name] = true
+				}
+			}
+		}
+		if i == 0 {
+			for repoPath := range paths {
+				changed = append(changed, repoPath)
+			}
+			continue
+		}
+		// A merge only changed the paths that differ from every parent.
+		changed = slices.DeleteFunc(changed, func(repoPath string) bool { return !paths[repoPath] })
+	}
+	return changed, nil
+}
+
+// fileHistories returns the commits listed in the history section of each
+// of files, keyed by file, walking the log once for all of them.
+func (rc *repoCommon) fileHistories(files []string) (map[string][]*object.Commit, error) {
+	var repo *git.Repository
+	var from plumbing.Hash
+	repoPaths := make(map[string]string, len(files))
+	for _, filePath := range files {
+		r, h, repoPath, err := rc.locateHistory(filePath)
+		if err != nil || r == nil {
+			return nil, err
+		}
+		repo, from = r, h
+		repoPaths[repoPath] = filePath
+	}
+	if repo == nil {
+		return nil, nil
+	}
+
+	logs, err := fileLogs(repo, from, slices.Collect(maps.Keys(repoPaths)), rc.history.Commits)
+	if err != nil {
+		return nil, err
+	}
+	histories := make(map[string][]*object.Commit, len(logs))
+	for repoPath, commits := range logs {
+		histories[repoPaths[repoPath]] = commits
+	}
+	return histories, nil
+}
+
+// writeHistory writes the history section of filePath, listing commits, as
+// the entry at index. It reports false when the file has no history.
+func (rc *repoCommon) writeHistory(w io.Writer, index int, filePath string, commits []*object.Commit) (bool, error) {
+	if len(commits) == 0 {
+		return false, nil
+	}
+	_, _, repoPath, err := rc.locateHistory(filePath)
+	if err != nil {
+		return false, err
+	}
+
+	var sb strings.Builder
+	for i, c := range commits {
+		if i > 0 && rc.history.Patch {
+			sb.WriteString("\n")
+		}
+		subject, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+		fmt.Fprintf(&sb, "%s  %s  %s  %s\n", c.Hash.String()[:7], c.Author.When.Format("2006-01-02"), c.Author.Name, subject)
+		if rc.history.Patch {
+			patch, err := commitPatch(c, repoPath)
+			if err != nil {
+				return false, err
+			}
+			sb.WriteString(patch)
+		}
+	}
+	title := fmt.Sprintf("History of %s", filePath)
+	content := rc.redactText(filePath, strings.TrimSuffix(sb.String(), "\n"))
+	return true, rc.writeSection(w, index, title, content)
+}
+
+// commitPatch returns the unified diff of the changes c made to the file at
+// repoPath, compared to its first parent.
+func commitPatch(c *object.Commit, repoPath string) (string, error) {
+	to, err := commitFile(c, repoPath)
+	if err != nil {
+		return "", err
+	}
+	var from []byte
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return "", err
+		}
+		if from, err = commitFile(parent, repoPath); err != nil {
+			return "", err
+		}
+	}
+
+	status := ChangeModified
+	switch {
+	case from == nil:
+		status = ChangeAdded
+	case to == nil:
+		status = ChangeDeleted
+	}
+	return unifiedDiff(repoPath, from, to, status)
+}
+
+// commitFile returns the contents of the file at repoPath in c, or nil if
+// it does not exist there.
+func commitFile(c *object.Commit, repoPath string) ([]byte, error) {
+	file, err := c.File(repoPath)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// locateHistory finds the Git repository of a local folder, the first time
+// it is asked for, and the path of filePath in it. It returns a nil
+// repository when the folder is not in a Git working tree.
+func (l *localRepository) locateHistory(filePath string) (*git.Repository, plumbing.Hash, string, error) {
+	l.historyOnce.Do(func() {
+		repo, err := git.PlainOpenWithOptions(l.root, &git.PlainOpenOptions{DetectDotGit: true})
+		if errors.Is(err, git.ErrRepositoryNotExists) {
+			return
+		}
+		if err != nil {
+			l.historyErr = err
+			return
+		}
+		wt, err := repo.Worktree()
+		if err != nil {
+			l.historyErr = err
+			return
+		}
+		if l.historyPrefix, l.historyErr = worktreePrefix(wt.Filesystem.Root(), l.root); l.historyErr != nil {
+			return
+		}
+		l.historyRepo = repo
+	})
+	if l.historyRepo == nil {
+		return nil, plumbing.ZeroHash, "", l.historyErr
+	}
+	return l.historyRepo, plumbing.ZeroHash, l.historyPrefix + filepath.ToSlash(filePath), nil
+}
+
+func (g *gitRepository) locateHistory(filePath string) (*git.Repository, plumbing.Hash, string, error) {
+	return g.repo, g.commit, filepath.ToSlash(filePath), nil
+}
@@ -0,0 +1,159 @@
+package gcat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestWithHistory(t *testing.T) {
+	t.Parallel()
+
+	dir := newDiffWorktree(t)
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	second := head.Hash().String()[:7]
+	commit, err := repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+	parent, err := commit.Parent(0)
+	require.NoError(t, err)
+	initial := parent.Hash.String()[:7]
+
+	day := func(sec int64) string { return time.Unix(sec, 0).Format("2006-01-02") }
+	secondLine := second + "  " + day(1700000100) + "  gcat  second"
+	initialLine := initial + "  " + day(1700000000) + "  gcat  initial"
+
+	tests := []struct {
+		name  string
+		root  string
+		opts  HistoryOptions
+		files []string
+		want  string
+	}{
+		{
+			name:  "last commit",
+			root:  dir,
+			opts:  HistoryOptions{Commits: 1},
+			files: []string{"a.txt"},
+			want:  "==> a.txt <==\none\n2\nthree\n\n\n==> History of a.txt <==\n" + secondLine,
+		},
+		{
+			name:  "commits that touched the file",
+			root:  dir,
+			opts:  HistoryOptions{Commits: 5},
+			files: []string{"a.txt", "untracked.txt"},
+			want: "==> a.txt <==\none\n2\nthree\n\n\n==> History of a.txt <==\n" + secondLine + "\n" + initialLine + "\n\n" +
+				"==> untracked.txt <==\nnot part of the diff\n",
+		},
+		{
+			name:  "subfolder",
+			root:  filepath.Join(dir, "sub"),
+			opts:  HistoryOptions{Commits: 5},
+			files: []string{"d.txt"},
+			want:  "==> d.txt <==\nadded\n\n\n==> History of d.txt <==\n" + secondLine,
+		},
+		{
+			name:  "patches",
+			root:  dir,
+			opts:  HistoryOptions{Commits: 5, Patch: true},
+			files: []string{"a.txt"},
+			want: "==> a.txt <==\none\n2\nthree\n\n\n==> History of a.txt <==\n" + secondLine + "\n" +
+				"diff --git a/a.txt b/a.txt\n" +
+				"index 4cb29ea38f70d7c61b2a3a25b02e3bdf44905402..f04eb265ebd74fba2cddf0a6adf2a6a7f81c87aa 100644\n" +
+				"--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n\n" +
+				initialLine + "\n" +
+				"diff --git a/a.txt b/a.txt\nnew file mode 100644\n" +
+				"index 0000000000000000000000000000000000000000..4cb29ea38f70d7c61b2a3a25b02e3bdf44905402\n" +
+				"--- /dev/null\n+++ b/a.txt\n@@ -0,0 +1,3 @@\n+one\n+two\n+three",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, err := NewLocalRepository(tt.root, WithHistory(tt.opts), WithFormatter(PlainFormatter{}))
+			require.NoError(t, err)
+			got, err := repo.ConcatFiles(tt.files)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("outside of a working tree", func(t *testing.T) {
+		t.Parallel()
+
+		plain := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(plain, "a.txt"), []byte("a"), 0o644))
+		repo, err := NewLocalRepository(plain, WithHistory(HistoryOptions{Commits: 3}), WithFormatter(PlainFormatter{}))
+		require.NoError(t, err)
+		got, err := repo.ConcatFiles([]string{"a.txt"})
+		require.NoError(t, err)
+		assert.False(t, strings.Contains(got, "History"), got)
+	})
+}
+
+func TestFileLogs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	when := int64(1700000000)
+	commit := func(message string, files map[string]string, parents ...plumbing.Hash) plumbing.Hash {
+		t.Helper()
+		for name, content := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+			_, err := wt.Add(name)
+			require.NoError(t, err)
+		}
+		when += 100
+		signature := &object.Signature{Name: "gcat", Email: "gcat@example.com", When: time.Unix(when, 0)}
+		hash, err := wt.Commit(message, &git.CommitOptions{Author: signature, Committer: signature, Parents: parents})
+		require.NoError(t, err)
+		return hash
+	}
+
+	initial := commit("initial", map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+	second := commit("second", map[string]string{"a.txt": "a2"})
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}))
+	feature := commit("feature", map[string]string{"b.txt": "b2"})
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.Master}))
+	master := commit("master", map[string]string{"c.txt": "c2"})
+	merge := commit("merge", map[string]string{"b.txt": "b2"}, master, feature)
+	last := commit("last", map[string]string{"a.txt": "a3"})
+
+	hashes := func(commits []*object.Commit) []plumbing.Hash {
+		var hs []plumbing.Hash
+		for _, c := range commits {
+			hs = append(hs, c.Hash)
+		}
+		return hs
+	}
+
+	logs, err := fileLogs(repo, plumbing.ZeroHash, []string{"a.txt", "b.txt", "c.txt", "missing.txt"}, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{last, second, initial}, hashes(logs["a.txt"]))
+	assert.Equal(t, []plumbing.Hash{feature, initial}, hashes(logs["b.txt"]), "the merge is not attributed to files of one side")
+	assert.Equal(t, []plumbing.Hash{master, initial}, hashes(logs["c.txt"]))
+	assert.Empty(t, logs["missing.txt"])
+	assert.NotContains(t, hashes(logs["b.txt"]), merge)
+
+	logs, err = fileLogs(repo, master, []string{"a.txt", "b.txt"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{second}, hashes(logs["a.txt"]), "starts at from")
+	assert.Equal(t, []plumbing.Hash{initial}, hashes(logs["b.txt"]))
+}
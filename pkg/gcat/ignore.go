@@ -1,55 +1,17 @@
 package gcat
 
 import (
-	"bufio"
-	"os"
-	"path/filepath"
 	"strings"
 
-	"github.com/bmatcuk/doublestar/v4"
+	"github.com/timsexperiments/gcat/internal/gitignore"
 )
 
-func loadGitIgnore(dir string) ([]string, error) {
-	gitIgnorePath := filepath.Join(dir, ".gitignore")
-	file, err := os.Open(gitIgnorePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, err
-	}
-	defer file.Close()
+// defaultIgnore lists the version control directories that are never listed.
+var defaultIgnore = []string{".git", ".svn", ".hg", ".bzr"}
 
-	var patterns []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		patterns = append(patterns, line)
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-	return patterns, nil
-}
-
-func shouldIgnore(relPath string, patterns []string) bool {
-	relPath = filepath.ToSlash(relPath)
-	for _, pattern := range patterns {
-		pattern = filepath.ToSlash(pattern)
-		if strings.HasSuffix(pattern, "/") {
-			trimmed := strings.TrimSuffix(pattern, "/")
-			if strings.HasPrefix(relPath, trimmed+"/") {
-				return true
-			}
-		} else {
-			matched, err := doublestar.PathMatch(pattern, relPath)
-			if err == nil && matched {
-				return true
-			}
-		}
-	}
-	return false
+// ignorePatterns parses the repository's built-in ignore patterns. They sit
+// below every .gitignore file, like .git/info/exclude does in git.
+func (l *localRepository) ignorePatterns() []gitignore.Pattern {
+	patterns, _ := gitignore.Parse(strings.NewReader(strings.Join(l.ignore, "\n")), "")
+	return patterns
 }
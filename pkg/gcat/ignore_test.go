@@ -1,53 +1,105 @@
 package gcat
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestShouldIgnore(t *testing.T) {
-	patterns := []string{
-		"*.log",
-		"temp/",
-		"vendor/",
-	}
+func TestLocalRepository_GetFilesGitignore(t *testing.T) {
+	t.Parallel()
 
 	tests := []struct {
-		relPath string
-		want    bool
+		name  string
+		files map[string]string
+		want  []string
 	}{
 		{
-			relPath: "error.log",
-			want:    true,
+			name: "negation re-includes files",
+			files: map[string]string{
+				".gitignore": "*.log\n!keep.log\n",
+				"debug.log":  "",
+				"keep.log":   "",
+				"main.go":    "",
+			},
+			want: []string{".gitignore", "keep.log", "main.go"},
 		},
 		{
-			relPath: "temp/file.txt",
-			want:    true,
+			name: "leading slash anchors to the .gitignore directory",
+			files: map[string]string{
+				".gitignore":   "/todo.txt\n",
+				"todo.txt":     "",
+				"sub/todo.txt": "",
+			},
+			want: []string{".gitignore", "sub/todo.txt"},
 		},
 		{
-			relPath: "vendor/package/file.go",
-			want:    true,
+			name: "nested rules apply below their directory only",
+			files: map[string]string{
+				"a.txt":           "",
+				"sub/.gitignore":  "*.txt\n",
+				"sub/b.txt":       "",
+				"sub/deep/c.txt":  "",
+				"sub/deep/c.go":   "",
+				"other/d.txt":     "",
+				"other/.keep.txt": "",
+			},
+			want: []string{"a.txt", "other/.keep.txt", "other/d.txt", "sub/.gitignore", "sub/deep/c.go"},
 		},
 		{
-			relPath: "src/main.go",
-			want:    false,
+			name: "nested rules override their parents",
+			files: map[string]string{
+				".gitignore":     "*.gen.go\n",
+				"api/.gitignore": "!api.gen.go\n",
+				"api/api.gen.go": "",
+				"db.gen.go":      "",
+			},
+			want: []string{".gitignore", "api/.gitignore", "api/api.gen.go"},
 		},
 		{
-			relPath: "doc/readme.md",
-			want:    false,
+			name: "files inside ignored directories stay ignored",
+			files: map[string]string{
+				".gitignore":     "build/\n!build/keep.txt\n",
+				"build/keep.txt": "",
+				"main.go":        "",
+			},
+			want: []string{".gitignore", "main.go"},
 		},
 		{
-			relPath: "tempdir/file.txt",
-			want:    false,
+			name: "version control directories are skipped",
+			files: map[string]string{
+				".git/HEAD":    "",
+				"sub/.hg/data": "",
+				"main.go":      "",
+			},
+			want: []string{"main.go"},
 		},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.relPath, func(t *testing.T) {
-			got := shouldIgnore(filepath.ToSlash(tt.relPath), patterns)
-			if got != tt.want {
-				t.Errorf("shouldIgnore(%q, %v) = %v; want %v", tt.relPath, patterns, got, tt.want)
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+			}
+
+			repo, err := NewLocalRepository(dir)
+			require.NoError(t, err)
+
+			files, err := repo.GetFiles()
+			require.NoError(t, err)
+			want := make([]string, len(tt.want))
+			for i, name := range tt.want {
+				want[i] = filepath.FromSlash(name)
 			}
+			assert.Equal(t, want, files)
 		})
 	}
 }
@@ -0,0 +1,166 @@
+package gcat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// OversizePolicy decides what happens to a file that does not fit within the
+// limits set by WithMaxFileSize and WithMaxTotalSize.
+type OversizePolicy string
+
+// Oversize policies accepted by WithOversizePolicy and ParseOversizePolicy.
+const (
+	// OversizeTruncate keeps the start and end of the file, replacing the
+	// bytes in between with a "[... N bytes truncated ...]" marker. It is the
+	// default.
+	OversizeTruncate OversizePolicy = "truncate"
+	// OversizeSkip leaves the file's contents out entirely.
+	OversizeSkip OversizePolicy = "skip"
+)
+
+var oversizePolicies = []OversizePolicy{OversizeTruncate, OversizeSkip}
+
+// ParseOversizePolicy returns the oversize policy with the given name.
+func ParseOversizePolicy(name string) (OversizePolicy, error) {
+	for _, policy := range oversizePolicies {
+		if strings.EqualFold(name, string(policy)) {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown oversize policy %q (available: %s)", name, strings.Join(OversizePolicyNames(), ", "))
+}
+
+// OversizePolicyNames returns the names of the oversize policies.
+func OversizePolicyNames() []string {
+	names := make([]string, len(oversizePolicies))
+	for i, policy := range oversizePolicies {
+		names[i] = string(policy)
+	}
+	return names
+}
+
+// WithMaxFileSize limits how many bytes of each file are written. Larger
+// files are truncated or skipped according to the oversize policy, with a
+// note in the file's header. Zero, the default, means no limit.
+func WithMaxFileSize(n int64) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil {
+			rc.maxFileSize = max(n, 0)
+		}
+	}
+}
+
+// WithMaxTotalSize limits how many bytes of file contents a single call to
+// ConcatFiles or ConcatTo writes, counting files in output order. The file
+// that crosses the limit is truncated or skipped according to the oversize
+// policy, and the files after it are skipped, each with a note in its header.
+// Zero, the default, means no limit.
+func WithMaxTotalSize(n int64) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil {
+			rc.maxTotalSize = max(n, 0)
+		}
+	}
+}
+
+// WithOversizePolicy sets what happens to files over the size limits. The
+// default is OversizeTruncate.
+func WithOversizePolicy(policy OversizePolicy) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil && policy != "" {
+			rc.oversize = policy
+		}
+	}
+}
+
+// sizeLimit returns how many bytes of a file of the given size may be written
+// when used bytes have already been written, and a description of the limit
+// that applies. keep is at least size when the file fits.
+func (rc *repoCommon) sizeLimit(size, used int64) (keep int64, limit string) {
+	keep = size
+	if rc.maxFileSize > 0 && rc.maxFileSize < keep {
+		keep = rc.maxFileSize
+		limit = fmt.Sprintf("file size limit of %d bytes", rc.maxFileSize)
+	}
+	if rc.maxTotalSize > 0 {
+		if remaining := max(rc.maxTotalSize-used, 0); remaining < keep {
+			keep = remaining
+			limit = fmt.Sprintf("total size limit of %d bytes", rc.maxTotalSize)
+		}
+	}
+	return keep, limit
+}
+
+// limitFile applies the size limits to file, whose contents are size bytes
+// long, updating its contents and note. It returns the number of bytes of
+// contents that will be written. Binary files are never truncated since the
+// result could not be decoded; they are skipped instead.
+func (rc *repoCommon) limitFile(file *File, size, used int64, binary bool) (int64, error) {
+	keep, limit := rc.sizeLimit(size, used)
+	if keep >= size {
+		return size, nil
+	}
+	if rc.oversize == OversizeSkip || binary || keep == 0 {
+		file.Content = strings.NewReader("")
+		file.Note = fmt.Sprintf("skipped: %d bytes exceeds the %s", size, limit)
+		return 0, nil
+	}
+	content, kept, err := truncate(file.Content, size, keep)
+	if err != nil {
+		return 0, err
+	}
+	file.Content = content
+	file.Note = fmt.Sprintf("truncated: %d of %d bytes shown, %s", kept, size, limit)
+	return kept, nil
+}
+
+// truncate keeps the first and last bytes of r, which holds size bytes, so
+// that at most keep bytes remain. The bytes in between are replaced with a
+// "[... N bytes truncated ...]" marker on a line of its own. Cuts never split
+// a UTF-8 encoded character. It returns the new contents and the number of
+// bytes kept.
+func truncate(r io.Reader, size, keep int64) (io.Reader, int64, error) {
+	headLen := keep - keep/2
+	tailLen := keep / 2
+
+	head := make([]byte, headLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, 0, err
+	}
+	head = trimPartialRune(head[:n])
+
+	if _, err := io.CopyN(io.Discard, r, size-headLen-tailLen); err != nil && !errors.Is(err, io.EOF) {
+		return nil, 0, err
+	}
+	tail, err := io.ReadAll(io.LimitReader(r, tailLen))
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := 0; i < utf8.UTFMax && len(tail) > 0 && !utf8.RuneStart(tail[0]); i++ {
+		tail = tail[1:]
+	}
+
+	kept := int64(len(head) + len(tail))
+	marker := fmt.Sprintf("\n[... %d bytes truncated ...]\n", size-kept)
+	return io.MultiReader(bytes.NewReader(head), strings.NewReader(marker), bytes.NewReader(tail)), kept, nil
+}
+
+// trimPartialRune drops an incomplete UTF-8 encoded character from the end of
+// b.
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
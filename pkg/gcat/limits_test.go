@@ -0,0 +1,151 @@
+package gcat
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		content  string
+		keep     int64
+		want     string
+		wantKept int64
+	}{
+		{
+			name:     "keeps head and tail",
+			content:  "0123456789abcdefghij",
+			keep:     6,
+			want:     "012\n[... 14 bytes truncated ...]\nhij",
+			wantKept: 6,
+		},
+		{
+			name:     "odd limit favours the head",
+			content:  "0123456789",
+			keep:     5,
+			want:     "012\n[... 5 bytes truncated ...]\n89",
+			wantKept: 5,
+		},
+		{
+			name:     "does not split characters",
+			content:  "ééééé",
+			keep:     5,
+			want:     "é\n[... 6 bytes truncated ...]\né",
+			wantKept: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, kept, err := truncate(strings.NewReader(tt.content), int64(len(tt.content)), tt.keep)
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.wantKept, kept)
+		})
+	}
+}
+
+func TestParseOversizePolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := ParseOversizePolicy("Skip")
+	require.NoError(t, err)
+	assert.Equal(t, OversizeSkip, policy)
+
+	_, err = ParseOversizePolicy("drop")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "truncate, skip")
+}
+
+func TestLocalRepository_SizeLimits(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("0123456789"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("abc"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.txt"), []byte("ABCDEF"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "d.bin"), []byte("\x00\x01\x02\x03\x04\x05\x06\x07"), 0o644))
+	files := []string{"a.txt", "b.txt", "c.txt"}
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		files []string
+		want  string
+	}{
+		{
+			name: "no limits",
+			want: "==> a.txt <==\n0123456789\n\n==> b.txt <==\nabc\n\n==> c.txt <==\nABCDEF",
+		},
+		{
+			name: "file size limit truncates",
+			opts: []Option{WithMaxFileSize(4)},
+			want: "==> a.txt (truncated: 4 of 10 bytes shown, file size limit of 4 bytes) <==\n01\n[... 6 bytes truncated ...]\n89\n\n" +
+				"==> b.txt <==\nabc\n\n" +
+				"==> c.txt (truncated: 4 of 6 bytes shown, file size limit of 4 bytes) <==\nAB\n[... 2 bytes truncated ...]\nEF",
+		},
+		{
+			name: "file size limit skips",
+			opts: []Option{WithMaxFileSize(4), WithOversizePolicy(OversizeSkip)},
+			want: "==> a.txt (skipped: 10 bytes exceeds the file size limit of 4 bytes) <==\n\n\n" +
+				"==> b.txt <==\nabc\n\n" +
+				"==> c.txt (skipped: 6 bytes exceeds the file size limit of 4 bytes) <==\n",
+		},
+		{
+			name: "total size limit truncates the file that crosses it",
+			opts: []Option{WithMaxTotalSize(15)},
+			want: "==> a.txt <==\n0123456789\n\n" +
+				"==> b.txt <==\nabc\n\n" +
+				"==> c.txt (truncated: 2 of 6 bytes shown, total size limit of 15 bytes) <==\nA\n[... 4 bytes truncated ...]\nF",
+		},
+		{
+			name: "total size limit skips the files after it is reached",
+			opts: []Option{WithMaxTotalSize(13), WithOversizePolicy(OversizeSkip)},
+			want: "==> a.txt <==\n0123456789\n\n" +
+				"==> b.txt <==\nabc\n\n" +
+				"==> c.txt (skipped: 6 bytes exceeds the total size limit of 13 bytes) <==\n",
+		},
+		{
+			name:  "binary files are skipped rather than truncated",
+			opts:  []Option{WithMaxFileSize(4), WithBinaryPolicy(BinaryBase64)},
+			files: []string{"b.txt", "d.bin"},
+			want:  "==> b.txt <==\nabc\n\n==> d.bin (skipped: 8 bytes exceeds the file size limit of 4 bytes) <==\n",
+		},
+		{
+			name:  "binary placeholders are not limited",
+			opts:  []Option{WithMaxFileSize(4), WithBinaryPolicy(BinaryPlaceholder)},
+			files: []string{"d.bin"},
+			want:  "==> d.bin <==\n[binary file, 8 bytes]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, err := NewLocalRepository(dir, append(tt.opts, WithFormatter(PlainFormatter{}))...)
+			require.NoError(t, err)
+
+			selected := tt.files
+			if selected == nil {
+				selected = files
+			}
+			got, err := repo.ConcatFiles(selected)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
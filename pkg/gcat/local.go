@@ -1,93 +1,129 @@
 package gcat
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
-	"sort"
-	"strings"
+	"sync"
 
-	"github.com/bmatcuk/doublestar/v4"
+	"github.com/timsexperiments/gcat/internal/gitignore"
+	git "gopkg.in/src-d/go-git.v4"
 )
 
-var defaultIgnore = []string{".git", ".svn", ".hg", ".bzr"}
-
 type localRepository struct {
-	root   string
-	common *repoCommon
-	ignore []string
+	root      string
+	common    *repoCommon
+	ignore    []string
+	gitIndex  bool
+	untracked bool
+
+	// The Git repository history is read from, opened on first use.
+	historyOnce   sync.Once
+	historyRepo   *git.Repository
+	historyPrefix string
+	historyErr    error
 }
 
 func (l *localRepository) GetFiles() ([]string, error) {
+	return l.GetFilesContext(context.Background())
+}
+
+func (l *localRepository) GetFilesContext(ctx context.Context) ([]string, error) {
+	var files []string
+	var err error
+	if l.gitIndex {
+		files, err = l.gitFiles(ctx)
+	} else {
+		files, err = l.walkFiles(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l.common.listFiles(ctx, files, l.open)
+}
+
+// walkFiles lists the files below l.root that are not ignored, stopping once
+// ctx is done.
+func (l *localRepository) walkFiles(ctx context.Context) ([]string, error) {
+	rules := gitignore.NewStack(l.ignorePatterns())
 	var files []string
 	err := filepath.WalkDir(l.root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-
-		ignoredContent, err := loadGitIgnore(filepath.Dir(path))
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.root, path)
 		if err != nil {
 			return err
 		}
-		for _, pattern := range ignoredContent {
-			l.registerIgnore(filepath.Join(filepath.Dir(path), pattern))
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
 		}
 
-		if l.shouldIgnore(path) {
+		if rel != "" && rules.Match(rel, d.IsDir()) {
 			if d.IsDir() {
 				return fs.SkipDir
 			}
 			return nil
 		}
-		if !d.IsDir() {
-			rel, err := filepath.Rel(l.root, path)
-			if err != nil {
-				return err
-			}
-			files = append(files, rel)
+		if d.IsDir() {
+			return rules.PushFile(rel, path)
 		}
+		files = append(files, filepath.FromSlash(rel))
 		return nil
 	})
 	return files, err
 }
 
 func (l *localRepository) GetFileContent(filePath string) (string, error) {
-	fullPath := filepath.Join(l.root, filePath)
-	data, err := os.ReadFile(fullPath)
+	return l.GetFileContentContext(context.Background(), filePath)
+}
+
+func (l *localRepository) GetFileContentContext(ctx context.Context, filePath string) (string, error) {
+	return readContent(ctx, filePath, l.open)
+}
+
+func (l *localRepository) ConcatFiles(files []string) (string, error) {
+	return l.ConcatFilesContext(context.Background(), files)
+}
+
+func (l *localRepository) ConcatFilesContext(ctx context.Context, files []string) (string, error) {
+	return l.common.concatFiles(ctx, files, l.open)
+}
+
+func (l *localRepository) ConcatTo(ctx context.Context, w io.Writer, files []string) error {
+	return l.common.concatTo(ctx, w, files, l.open)
+}
+
+func (l *localRepository) open(filePath string) (io.ReadCloser, int64, error) {
+	file, err := os.Open(filepath.Join(l.root, filePath))
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
 	if err != nil {
-		return "", err
+		file.Close()
+		return nil, 0, err
 	}
-	return string(data), nil
+	return file, info.Size(), nil
 }
 
-func (l *localRepository) ConcatFiles(files []string) (string, error) {
-	var sb strings.Builder
-	sort.Strings(files)
-	for i, filePath := range files {
-		content, err := l.GetFileContent(filePath)
-		if err != nil {
-			return "", err
-		} else {
-			lang := l.common.getLanguage(filePath)
-			if lang != "" {
-				sb.WriteString(fmt.Sprintf("%s (%s):\n\n", filePath, lang))
-			} else {
-				sb.WriteString(fmt.Sprintf("%s:\n\n", filePath))
-			}
-			sb.WriteString("<contents>\n")
-			sb.WriteString(content)
-			sb.WriteString("\n</contents>")
-		}
-		if i < len(files)-1 {
-			sb.WriteString("\n\n---\n\n")
-		}
+func (l *localRepository) FileSize(filePath string) (int64, error) {
+	info, err := os.Stat(filepath.Join(l.root, filePath))
+	if err != nil {
+		return 0, err
 	}
-	return sb.String(), nil
+	return info.Size(), nil
 }
 
 func (l *localRepository) GetLanguage(filePath string) string {
-	return l.common.getLanguage(filePath)
+	return l.common.languageOf(filePath, l.open)
 }
 
 func NewLocalRepository(root string, opts ...Option) (Repository, error) {
@@ -99,22 +135,10 @@ func NewLocalRepository(root string, opts ...Option) (Repository, error) {
 		return nil, fmt.Errorf("%s is not a directory", root)
 	}
 	repo := &localRepository{root: root, common: newRepoCommon(), ignore: defaultIgnore}
+	repo.common.getFiles = repo.GetFilesContext
+	repo.common.locateHistory = repo.locateHistory
 	for _, opt := range opts {
 		opt(repo)
 	}
 	return repo, nil
 }
-
-func (l *localRepository) registerIgnore(ignore string) {
-	l.ignore = append(l.ignore, ignore)
-}
-
-func (l *localRepository) shouldIgnore(filePath string) bool {
-	for _, pattern := range l.ignore {
-		matched, err := doublestar.Match(pattern, filePath)
-		if err == nil && matched {
-			return true
-		}
-	}
-	return false
-}
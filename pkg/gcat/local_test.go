@@ -1,6 +1,10 @@
 package gcat
 
 import (
+	"context"
+	"io/fs"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -217,3 +221,94 @@ func TestLocalRepository_GetFileContent(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalRepository_ConcatTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ctx     func() context.Context
+		files   []string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "streams files in sorted order",
+			ctx:   context.Background,
+			files: []string{"nested/nested_file.txt", "file1.txt"},
+			want:  "file1.txt (Text):\n\n<contents>\ntest content\n</contents>\n\n---\n\nnested/nested_file.txt (Text):\n\n<contents>\n\n</contents>",
+		},
+		{
+			name: "stops when the context is canceled",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			files:   []string{"file1.txt"},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, err := NewLocalRepository("testdata/no-ignore")
+			require.NoError(t, err)
+
+			files := slices.Clone(tt.files)
+			var sb strings.Builder
+			err = repo.ConcatTo(tt.ctx(), &sb, files)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sb.String())
+			assert.Equal(t, tt.files, files, "ConcatTo must not reorder its input")
+		})
+	}
+}
+
+func TestLocalRepository_FileSize(t *testing.T) {
+	t.Parallel()
+
+	repo, err := NewLocalRepository("testdata/no-ignore")
+	require.NoError(t, err)
+
+	size, err := repo.FileSize("file1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("test content")), size)
+
+	_, err = repo.FileSize("non-existent.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestLocalRepository_Context(t *testing.T) {
+	t.Parallel()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, opts := range [][]Option{nil, {WithGitIndex(true)}} {
+		repo, err := NewLocalRepository("testdata/no-ignore", opts...)
+		require.NoError(t, err)
+
+		_, err = repo.GetFilesContext(canceled)
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+
+	repo, err := NewLocalRepository("testdata/no-ignore")
+	require.NoError(t, err)
+	_, err = repo.GetFileContentContext(canceled, "file1.txt")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.ConcatFilesContext(canceled, []string{"file1.txt"})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = OpenRepositoryContext(canceled, "testdata/no-ignore")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	content, err := repo.GetFileContentContext(context.Background(), "file1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "test content", content)
+}
@@ -0,0 +1,138 @@
+package gcat
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"maps"
+)
+
+// Summarizer condenses a source file to its outline, such as its
+// declarations without their bodies, so that the structure of a file costs
+// far fewer tokens than its contents.
+type Summarizer interface {
+	Summarize(filePath string, src []byte) ([]byte, error)
+}
+
+// outlineNote is the note of files written as their outline.
+const outlineNote = "outline, bodies elided"
+
+// DefaultSummarizers returns the built-in summarizers keyed by the language
+// they handle.
+func DefaultSummarizers() map[string]Summarizer {
+	return map[string]Summarizer{
+		"Go": GoSummarizer{},
+	}
+}
+
+// WithOutline writes files in languages that have a summarizer as their
+// outline instead of their full contents, noting so in their header. Files
+// in other languages, and files a summarizer fails on, are written in full.
+// It uses DefaultSummarizers along with any added with WithSummarizer.
+func WithOutline() Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil {
+			summarizers := DefaultSummarizers()
+			maps.Copy(summarizers, rc.summarizers)
+			rc.summarizers = summarizers
+			rc.outline = true
+		}
+	}
+}
+
+// WithSummarizer writes files in language as their outline using s,
+// replacing any built-in summarizer for it. Outlines are only written once
+// WithOutline is also given.
+func WithSummarizer(language string, s Summarizer) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil {
+			if rc.summarizers == nil {
+				rc.summarizers = map[string]Summarizer{}
+			}
+			rc.summarizers[language] = s
+		}
+	}
+}
+
+// outlineFile replaces the contents of file, which has size bytes, with its
+// outline when outlines are enabled and its language has a summarizer. It
+// returns the size of the contents and whether they were replaced.
+func (rc *repoCommon) outlineFile(file *File, size int64) (int64, bool, error) {
+	if !rc.outline {
+		return size, false, nil
+	}
+	s, ok := rc.summarizers[file.Language]
+	if !ok {
+		return size, false, nil
+	}
+	src, err := io.ReadAll(file.Content)
+	if err != nil {
+		return 0, false, err
+	}
+	outline, err := s.Summarize(file.Path, src)
+	if err != nil {
+		file.Content = bytes.NewReader(src)
+		return int64(len(src)), false, nil
+	}
+	file.Content = bytes.NewReader(outline)
+	return int64(len(outline)), true, nil
+}
+
+// GoSummarizer outlines Go source files: the package clause, imports,
+// constants, variables, types and function and method signatures, along with
+// their doc comments, with function bodies elided.
+type GoSummarizer struct{}
+
+func (GoSummarizer) Summarize(filePath string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filePath, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remember the span of every elided body so the comments inside it can
+	// be dropped too; the printer would otherwise place them elsewhere.
+	var elided [][2]token.Pos
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				elided = append(elided, [2]token.Pos{n.Body.Lbrace, n.Body.Rbrace})
+				n.Body = nil
+			}
+		case *ast.FuncLit:
+			elided = append(elided, [2]token.Pos{n.Body.Lbrace + 1, n.Body.Rbrace})
+			n.Body = &ast.BlockStmt{Lbrace: n.Body.Lbrace, Rbrace: n.Body.Lbrace + 1}
+			return false
+		}
+		return true
+	})
+
+	comments := f.Comments[:0]
+	for _, group := range f.Comments {
+		inside := false
+		for _, span := range elided {
+			if group.Pos() >= span[0] && group.End() <= span[1]+1 {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			comments = append(comments, group)
+		}
+	}
+	f.Comments = comments
+
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, fset, f); err != nil {
+		return nil, err
+	}
+	if buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
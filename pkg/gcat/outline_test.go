@@ -0,0 +1,194 @@
+package gcat
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timsexperiments/gcat/pkg/tokenizer"
+)
+
+const outlineTestSource = `// Package shapes measures shapes.
+package shapes
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Pi is the ratio of a circle's circumference to its diameter.
+const Pi = math.Pi
+
+// Shape is anything with an area.
+type Shape interface {
+	Area() float64
+}
+
+// Circle is a round shape.
+type Circle struct {
+	Radius float64 // in meters
+}
+
+// Area returns the area of the circle.
+func (c Circle) Area() float64 {
+	// Multiply by the radius twice.
+	return Pi * c.Radius * c.Radius
+}
+
+var describe = func(s Shape) string {
+	// Keep it short.
+	return fmt.Sprint(s.Area())
+}
+
+// Largest returns the shape with the largest area.
+func Largest(shapes ...Shape) Shape {
+	var largest Shape
+	for _, s := range shapes {
+		if largest == nil || s.Area() > largest.Area() {
+			largest = s
+		}
+	}
+	return largest
+}
+`
+
+func TestGoSummarizer(t *testing.T) {
+	t.Parallel()
+
+	got, err := GoSummarizer{}.Summarize("shapes.go", []byte(outlineTestSource))
+	require.NoError(t, err)
+	assert.Equal(t, `// Package shapes measures shapes.
+package shapes
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Pi is the ratio of a circle's circumference to its diameter.
+const Pi = math.Pi
+
+// Shape is anything with an area.
+type Shape interface {
+	Area() float64
+}
+
+// Circle is a round shape.
+type Circle struct {
+	Radius float64 // in meters
+}
+
+// Area returns the area of the circle.
+func (c Circle) Area() float64
+
+var describe = func(s Shape) string {}
+
+// Largest returns the shape with the largest area.
+func Largest(shapes ...Shape) Shape
+`, string(got))
+
+	_, err = GoSummarizer{}.Summarize("broken.go", []byte("package broken\n\nfunc {"))
+	assert.Error(t, err)
+}
+
+// upperSummarizer outlines files by upper-casing them, failing on empty ones.
+type upperSummarizer struct{}
+
+func (upperSummarizer) Summarize(_ string, src []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, errors.New("empty file")
+	}
+	return []byte(strings.ToUpper(string(src))), nil
+}
+
+func TestWithOutline(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"shapes.go": outlineTestSource,
+		"broken.go": "package broken\n\nfunc {",
+		"notes.txt": "some notes",
+		"empty.txt": "",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		files []string
+		want  string
+	}{
+		{
+			name:  "go files are outlined",
+			opts:  []Option{WithOutline()},
+			files: []string{"shapes.go", "notes.txt"},
+			want:  "==> notes.txt <==\nsome notes\n\n==> shapes.go (outline, bodies elided) <==\n",
+		},
+		{
+			name:  "files that fail to parse are written in full",
+			opts:  []Option{WithOutline()},
+			files: []string{"broken.go"},
+			want:  "==> broken.go <==\npackage broken\n\nfunc {",
+		},
+		{
+			name:  "custom summarizer",
+			opts:  []Option{WithSummarizer("Text", upperSummarizer{}), WithOutline()},
+			files: []string{"empty.txt", "notes.txt"},
+			want:  "==> empty.txt <==\n\n\n==> notes.txt (outline, bodies elided) <==\nSOME NOTES",
+		},
+		{
+			name:  "summarizers are unused without outline mode",
+			opts:  []Option{WithSummarizer("Text", upperSummarizer{})},
+			files: []string{"notes.txt"},
+			want:  "==> notes.txt <==\nsome notes",
+		},
+		{
+			name:  "limits apply to the outline",
+			opts:  []Option{WithOutline(), WithMaxFileSize(20)},
+			files: []string{"shapes.go"},
+			want:  "==> shapes.go (outline, bodies elided; truncated: 20 of ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]Option{WithFormatter(PlainFormatter{})}, tt.opts...)
+			repo, err := NewLocalRepository(dir, opts...)
+			require.NoError(t, err)
+			got, err := repo.ConcatFiles(tt.files)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(got, tt.want), got)
+		})
+	}
+}
+
+func TestCountTokens_Outline(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shapes.go"), []byte(outlineTestSource), 0o644))
+
+	full, err := NewLocalRepository(dir)
+	require.NoError(t, err)
+	fullCounts, err := CountTokens(context.Background(), full, []string{"shapes.go"}, tokenizer.Heuristic{})
+	require.NoError(t, err)
+
+	outlined, err := NewLocalRepository(dir, WithOutline())
+	require.NoError(t, err)
+	outlineCounts, err := CountTokens(context.Background(), outlined, []string{"shapes.go"}, tokenizer.Heuristic{})
+	require.NoError(t, err)
+
+	assert.Less(t, outlineCounts.Total, fullCounts.Total)
+}
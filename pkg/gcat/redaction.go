@@ -0,0 +1,80 @@
+package gcat
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/timsexperiments/gcat/pkg/redact"
+)
+
+// WithRedactor replaces the secrets r finds in the text files written by
+// ConcatFiles and ConcatTo, and in their history, with typed placeholders
+// such as "[REDACTED:private-key]", noting how many were redacted in each
+// file's header. r keeps count of what was redacted; see redact.Redactor.
+// Binary files are not scanned. A nil r, the default, disables redaction.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(repo Repository) {
+		if rc := commonOf(repo); rc != nil {
+			rc.redactor = r
+		}
+	}
+}
+
+// redactFile replaces the secrets in the contents of file, which has size
+// bytes, when a redactor is set. It returns the size of the contents and a
+// note on what was redacted, if anything. Contents over maxBufferedFile are
+// not held in memory: they are scanned once to count the secrets and the size
+// of the redacted contents, and then read again through reopen and redacted
+// as they are written. A nil reopen means the contents are in memory already.
+func (rc *repoCommon) redactFile(file *File, size int64, reopen func() (io.Reader, error)) (int64, string, error) {
+	if rc.redactor == nil {
+		return size, "", nil
+	}
+	if size > maxBufferedFile && reopen != nil {
+		return rc.redactLargeFile(file, reopen)
+	}
+	src, err := io.ReadAll(file.Content)
+	if err != nil {
+		return 0, "", err
+	}
+	redacted, matches := rc.redactor.Redact(file.Path, string(src))
+	file.Content = strings.NewReader(redacted)
+	if len(matches) == 0 {
+		return int64(len(src)), "", nil
+	}
+	return int64(len(redacted)), redactedNote(len(matches)), nil
+}
+
+// redactLargeFile redacts the contents of file like redactFile without
+// reading them into memory.
+func (rc *repoCommon) redactLargeFile(file *File, reopen func() (io.Reader, error)) (int64, string, error) {
+	matches, size, err := rc.redactor.Scan(file.Path, file.Content)
+	if err != nil {
+		return 0, "", err
+	}
+	content, err := reopen()
+	if err != nil {
+		return 0, "", err
+	}
+	file.Content = rc.redactor.NewReader(file.Path, content)
+	if matches == 0 {
+		return size, "", nil
+	}
+	return size, redactedNote(matches), nil
+}
+
+// redactedNote notes that n secrets were redacted from a file.
+func redactedNote(n int) string {
+	return fmt.Sprintf("redacted: %s", plural(n, "secret"))
+}
+
+// redactText replaces the secrets in text belonging to filePath when a
+// redactor is set.
+func (rc *repoCommon) redactText(filePath, text string) string {
+	if rc.redactor == nil {
+		return text
+	}
+	redacted, _ := rc.redactor.Redact(filePath, text)
+	return redacted
+}
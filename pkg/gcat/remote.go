@@ -1,114 +1,410 @@
 package gcat
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 
 	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
 	"gopkg.in/src-d/go-git.v4/storage/memory"
 )
 
 type gitRepository struct {
 	repo   *git.Repository
 	common *repoCommon
+	ref    string
+	auth   transport.AuthMethod
+	commit plumbing.Hash
+	cache  *CloneCache
+
+	// The files of the commit's tree, listed on first use.
+	indexOnce sync.Once
+	index     *treeIndex
+	indexErr  error
+}
+
+// treeIndex lists the files of a tree once, so that they can be looked up
+// without walking the tree again, from several goroutines at once.
+type treeIndex struct {
+	// paths are the paths of the files in tree order.
+	paths []string
+	files map[string]*object.File
 }
 
 func (g *gitRepository) GetFiles() ([]string, error) {
-	ref, err := g.repo.Head()
-	if err != nil {
+	return g.GetFilesContext(context.Background())
+}
+
+func (g *gitRepository) GetFilesContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	commit, err := g.repo.CommitObject(ref.Hash())
+	index, err := g.files()
 	if err != nil {
 		return nil, err
 	}
-	tree, err := commit.Tree()
+	return g.common.listFiles(ctx, slices.Clone(index.paths), g.open)
+}
+
+// files returns the index of the files of the commit's tree.
+func (g *gitRepository) files() (*treeIndex, error) {
+	g.indexOnce.Do(func() {
+		tree, err := g.tree()
+		if err != nil {
+			g.indexErr = err
+			return
+		}
+		index := &treeIndex{files: make(map[string]*object.File)}
+		g.indexErr = tree.Files().ForEach(func(f *object.File) error {
+			index.paths = append(index.paths, f.Name)
+			index.files[f.Name] = f
+			return nil
+		})
+		g.index = index
+	})
+	return g.index, g.indexErr
+}
+
+func (g *gitRepository) GetFileContent(filePath string) (string, error) {
+	return g.GetFileContentContext(context.Background(), filePath)
+}
+
+func (g *gitRepository) GetFileContentContext(ctx context.Context, filePath string) (string, error) {
+	return readContent(ctx, filePath, g.open)
+}
+
+func (g *gitRepository) ConcatFiles(files []string) (string, error) {
+	return g.ConcatFilesContext(context.Background(), files)
+}
+
+func (g *gitRepository) ConcatFilesContext(ctx context.Context, files []string) (string, error) {
+	return g.common.concatFiles(ctx, files, g.open)
+}
+
+func (g *gitRepository) ConcatTo(ctx context.Context, w io.Writer, files []string) error {
+	return g.common.concatTo(ctx, w, files, g.open)
+}
+
+func (g *gitRepository) open(filePath string) (io.ReadCloser, int64, error) {
+	file, err := g.file(filePath)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	var files []string
-	err = tree.Files().ForEach(func(f *object.File) error {
-		files = append(files, f.Name)
-		return nil
-	})
+	reader, err := file.Blob.Reader()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return files, nil
+	return reader, file.Blob.Size, nil
 }
 
-func (g *gitRepository) GetFileContent(filePath string) (string, error) {
-	ref, err := g.repo.Head()
+func (g *gitRepository) FileSize(filePath string) (int64, error) {
+	file, err := g.file(filePath)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
-	commit, err := g.repo.CommitObject(ref.Hash())
+	return file.Blob.Size, nil
+}
+
+// file looks up filePath in the tree of the resolved commit, reporting a
+// missing file with fs.ErrNotExist like the local repository does.
+func (g *gitRepository) file(filePath string) (*object.File, error) {
+	index, err := g.files()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	tree, err := commit.Tree()
-	if err != nil {
-		return "", err
+	file, ok := index.files[filepath.ToSlash(filePath)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: filePath, Err: fs.ErrNotExist}
 	}
-	file, err := tree.File(filePath)
+	return file, nil
+}
+
+func (g *gitRepository) GetLanguage(filePath string) string {
+	return g.common.languageOf(filePath, g.open)
+}
+
+// tree returns the tree of the commit the repository was resolved to.
+func (g *gitRepository) tree() (*object.Tree, error) {
+	commit, err := g.repo.CommitObject(g.commit)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	reader, err := file.Blob.Reader()
+	return commit.Tree()
+}
+
+// CloneGitRepository clones repoURL into memory, or fetches it into the
+// clone cache set with WithCloneCache.
+//
+// Without WithRef, only the latest commit of the default branch is fetched.
+// Branches and tags selected with WithRef, by name or by the SHA of the commit
+// they point to, are also fetched at depth 1, as is the default branch to look
+// for any other commit SHA. A commit that is not there requires fetching every
+// branch and tag with their full history, as does WithHistory. The clone
+// cache fetches the same refs at the same depth, keeping them for later runs.
+func CloneGitRepository(repoURL string, opts ...Option) (Repository, error) {
+	return CloneGitRepositoryContext(context.Background(), repoURL, opts...)
+}
+
+// CloneGitRepositoryContext is CloneGitRepository, giving up once ctx is done.
+func CloneGitRepositoryContext(ctx context.Context, repoURL string, opts ...Option) (Repository, error) {
+	repo := &gitRepository{common: newRepoCommon()}
+	repo.common.getFiles = repo.GetFilesContext
+	repo.common.locateHistory = repo.locateHistory
+	for _, opt := range opts {
+		opt(repo)
+	}
+	if err := repo.clone(ctx, repoURL); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (g *gitRepository) clone(ctx context.Context, repoURL string) error {
+	var refName plumbing.ReferenceName
+	if g.ref != "" {
+		var err error
+		if refName, err = findRemoteRef(ctx, repoURL, g.ref, g.auth); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	var gitRepo *git.Repository
+	var commit plumbing.Hash
+	var err error
+	if g.common.history == nil {
+		var rev string
+		if gitRepo, rev, err = g.shallowClone(ctx, repoURL, refName); err != nil {
+			return err
+		}
+		if g.ref != "" && refName == "" {
+			// A commit that is not the tip of a branch or tag may still be
+			// in the clone of the default branch.
+			rev = g.ref
+		}
+		commit, err = resolveCommit(gitRepo, rev)
+		if err != nil && refName != "" {
+			return err
+		}
+	}
+	if g.common.history != nil || err != nil {
+		// Listing the commits that touched a file, or finding a commit that
+		// is not in the shallow clone, needs all of them.
+		if gitRepo, err = g.fullClone(ctx, repoURL); err != nil {
+			return err
+		}
+		rev := g.ref
+		if rev == "" {
+			rev = headRevision(gitRepo)
+		}
+		if commit, err = resolveCommit(gitRepo, rev); err != nil {
+			return err
+		}
+	}
+
+	g.repo = gitRepo
+	g.commit = commit
+	return nil
+}
+
+// shallowClone fetches the latest commit of refName, a branch or tag, or of
+// the remote's HEAD when refName is empty, fetching it into the clone cache
+// when one is set. It returns the clone along with the revision it fetched.
+func (g *gitRepository) shallowClone(ctx context.Context, repoURL string, refName plumbing.ReferenceName) (*git.Repository, string, error) {
+	if g.cache != nil && cacheable(repoURL) {
+		src, dst := plumbing.HEAD, plumbing.NewRemoteReferenceName(git.DefaultRemoteName, "HEAD")
+		switch {
+		case refName.IsBranch():
+			src, dst = refName, plumbing.NewRemoteReferenceName(git.DefaultRemoteName, refName.Short())
+		case refName.IsTag():
+			src, dst = refName, refName
+		}
+		refSpec := config.RefSpec(fmt.Sprintf("+%s:%s", src, dst))
+		gitRepo, err := g.cache.fetch(ctx, repoURL, g.auth, refSpec)
+		if !errors.Is(err, errCacheBusy) {
+			return gitRepo, dst.String(), err
+		}
+	}
+	cloneOpts := &git.CloneOptions{URL: repoURL, Auth: g.auth, Depth: 1}
+	if refName != "" {
+		cloneOpts.ReferenceName = refName
+		cloneOpts.SingleBranch = true
+	}
+	gitRepo, err := git.CloneContext(ctx, memory.NewStorage(), nil, cloneOpts)
 	if err != nil {
-		return "", err
+		return nil, "", contextError(ctx, err)
 	}
-	defer reader.Close()
-	data, err := io.ReadAll(reader)
+	// The clone checks out what it fetched.
+	return gitRepo, plumbing.HEAD.String(), nil
+}
+
+// fullClone clones every branch and tag of repoURL with all of their
+// history, fetching them into the clone cache when one is set.
+func (g *gitRepository) fullClone(ctx context.Context, repoURL string) (*git.Repository, error) {
+	if g.cache != nil && cacheable(repoURL) {
+		gitRepo, err := g.cache.fetch(ctx, repoURL, g.auth, "")
+		if !errors.Is(err, errCacheBusy) {
+			return gitRepo, err
+		}
+	}
+	gitRepo, err := git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{URL: repoURL, Auth: g.auth, Tags: git.AllTags})
 	if err != nil {
-		return "", err
+		return nil, contextError(ctx, err)
 	}
-	return string(data), nil
+	return gitRepo, nil
 }
 
-func (g *gitRepository) ConcatFiles(files []string) (string, error) {
-	var sb strings.Builder
-	sort.Strings(files)
-	for i, filePath := range files {
-		content, err := g.GetFileContent(filePath)
-		if err != nil {
-			return "", err
-		} else {
-			lang := g.common.getLanguage(filePath)
-			if lang != "" {
-				sb.WriteString(fmt.Sprintf("%s (%s):\n\n", filePath, lang))
-			} else {
-				sb.WriteString(fmt.Sprintf("%s:\n\n", filePath))
+// headRevision returns the revision of the remote's HEAD in gitRepo: the
+// remote-tracking ref a cached clone fetches it into, or the HEAD of a clone
+// made into memory.
+func headRevision(gitRepo *git.Repository) string {
+	if _, err := gitRepo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, "HEAD"), false); err == nil {
+		return git.DefaultRemoteName + "/HEAD"
+	}
+	return "HEAD"
+}
+
+// contextError returns the error of ctx once it is done, since go-git does not
+// wrap it in the errors it returns, or err otherwise.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// findRemoteRef returns the full name of the branch or tag on the remote that
+// ref refers to, either by its name or, for a full or abbreviated commit SHA,
+// by the commit it points to. It returns "" if no branch or tag matches. It
+// gives up waiting for the remote once ctx is done.
+func findRemoteRef(ctx context.Context, repoURL, ref string, auth transport.AuthMethod) (plumbing.ReferenceName, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{repoURL},
+	})
+	// go-git cannot cancel listing the references, so stop waiting for it.
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	type result struct {
+		refs []*plumbing.Reference
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		refs, err := remote.List(&git.ListOptions{Auth: auth})
+		done <- result{refs, err}
+	}()
+	var refs []*plumbing.Reference
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return "", r.err
+		}
+		refs = r.refs
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Name() < refs[j].Name() })
+
+	candidates := []plumbing.ReferenceName{
+		plumbing.ReferenceName(ref),
+		plumbing.NewBranchReferenceName(ref),
+		plumbing.NewTagReferenceName(ref),
+	}
+	for _, candidate := range candidates {
+		for _, r := range refs {
+			if r.Name() == candidate && (candidate.IsBranch() || candidate.IsTag()) {
+				return candidate, nil
 			}
-			sb.WriteString("<contents>\n")
-			sb.WriteString(content)
-			sb.WriteString("\n</contents>")
 		}
-		if i < len(files)-1 {
-			sb.WriteString("\n\n---\n\n")
+	}
+
+	// A SHA matches the branches and tags at that commit, as long as the
+	// prefix is not shared by different commits. Annotated tags are
+	// advertised by the hash of the tag, not of its commit.
+	if len(ref) < 4 || len(ref) > 40 {
+		return "", nil
+	}
+	prefix := strings.ToLower(ref)
+	var match *plumbing.Reference
+	for _, r := range refs {
+		if !(r.Name().IsBranch() || r.Name().IsTag()) || !strings.HasPrefix(r.Hash().String(), prefix) {
+			continue
+		}
+		if match != nil && match.Hash() != r.Hash() {
+			return "", nil
 		}
+		if match == nil || (r.Name().IsBranch() && !match.Name().IsBranch()) {
+			match = r
+		}
+	}
+	if match == nil {
+		return "", nil
 	}
-	return sb.String(), nil
+	return match.Name(), nil
 }
 
-func (g *gitRepository) GetLanguage(filePath string) string {
-	return g.common.getLanguage(filePath)
-}
+// resolveCommit resolves rev, a ref name or a full or abbreviated commit SHA,
+// to the hash of a commit in repo. Branch names also match the branches of
+// the origin remote, which is all a full clone has of most branches.
+func resolveCommit(repo *git.Repository, rev string) (plumbing.Hash, error) {
+	for _, candidate := range []string{rev, git.DefaultRemoteName + "/" + rev} {
+		if hash, err := repo.ResolveRevision(plumbing.Revision(candidate)); err == nil {
+			return *hash, nil
+		}
+	}
+	if !isAbbreviatedHash(rev) {
+		return plumbing.ZeroHash, fmt.Errorf("ref %q not found", rev)
+	}
 
-func CloneGitRepository(repoURL string, opts ...Option) (Repository, error) {
-	gitRepo, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
-		URL:   repoURL,
-		Depth: 1,
+	commits, err := repo.CommitObjects()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	prefix := strings.ToLower(rev)
+	var matches []plumbing.Hash
+	err = commits.ForEach(func(c *object.Commit) error {
+		if strings.HasPrefix(c.Hash.String(), prefix) {
+			matches = append(matches, c.Hash)
+		}
+		return nil
 	})
 	if err != nil {
-		return nil, err
+		return plumbing.ZeroHash, err
 	}
-	repo := &gitRepository{repo: gitRepo, common: newRepoCommon()}
-	for _, opt := range opts {
-		opt(repo)
+	switch len(matches) {
+	case 0:
+		return plumbing.ZeroHash, fmt.Errorf("ref %q not found", rev)
+	case 1:
+		return matches[0], nil
+	default:
+		return plumbing.ZeroHash, fmt.Errorf("short SHA %q is ambiguous", rev)
 	}
-	return repo, nil
+}
+
+// isAbbreviatedHash reports whether s looks like a shortened commit SHA.
+func isAbbreviatedHash(s string) bool {
+	if len(s) < 4 || len(s) >= 40 {
+		return false
+	}
+	for _, c := range strings.ToLower(s) {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
 }
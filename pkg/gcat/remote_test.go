@@ -1,10 +1,20 @@
 package gcat
 
 import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
 const repository = "https://github.com/timsexperiments/gcat.git"
@@ -190,3 +200,284 @@ func TestRemoteRepository_GetFileContent(t *testing.T) {
 		})
 	}
 }
+
+// testRemote is a Git repository on disk that can be cloned without network
+// access.
+type testRemote struct {
+	url     string
+	commits map[string]plumbing.Hash
+}
+
+// newTestRemote creates a repository whose default branch has the commits
+// "one" and "two", a branch "feature" with commit "three", a lightweight tag
+// "v1" on "one" and an annotated tag "v2" on "two".
+func newTestRemote(t *testing.T) testRemote {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	signature := &object.Signature{Name: "gcat", Email: "gcat@example.com", When: time.Unix(1700000000, 0)}
+	commit := func(name string) plumbing.Hash {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".txt"), []byte(name), 0o644))
+		_, err := wt.Add(name + ".txt")
+		require.NoError(t, err)
+		hash, err := wt.Commit(name, &git.CommitOptions{Author: signature})
+		require.NoError(t, err)
+		return hash
+	}
+
+	remote := testRemote{url: dir, commits: map[string]plumbing.Hash{}}
+	remote.commits["one"] = commit("one")
+	_, err = repo.CreateTag("v1", remote.commits["one"], nil)
+	require.NoError(t, err)
+	remote.commits["two"] = commit("two")
+	_, err = repo.CreateTag("v2", remote.commits["two"], &git.CreateTagOptions{Tagger: signature, Message: "v2"})
+	require.NoError(t, err)
+
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}))
+	remote.commits["three"] = commit("three")
+	require.NoError(t, wt.Checkout(&git.CheckoutOptions{Branch: plumbing.Master}))
+
+	return remote
+}
+
+func TestRemoteRepository_WithRef(t *testing.T) {
+	t.Parallel()
+
+	remote := newTestRemote(t)
+
+	tests := []struct {
+		name        string
+		ref         func() string
+		want        []string
+		expectedErr string
+	}{
+		{
+			name: "default branch",
+			ref:  func() string { return "" },
+			want: []string{"one.txt", "two.txt"},
+		},
+		{
+			name: "branch",
+			ref:  func() string { return "feature" },
+			want: []string{"one.txt", "three.txt", "two.txt"},
+		},
+		{
+			name: "full branch reference",
+			ref:  func() string { return "refs/heads/feature" },
+			want: []string{"one.txt", "three.txt", "two.txt"},
+		},
+		{
+			name: "lightweight tag",
+			ref:  func() string { return "v1" },
+			want: []string{"one.txt"},
+		},
+		{
+			name: "annotated tag",
+			ref:  func() string { return "v2" },
+			want: []string{"one.txt", "two.txt"},
+		},
+		{
+			name: "full commit SHA",
+			ref:  func() string { return remote.commits["one"].String() },
+			want: []string{"one.txt"},
+		},
+		{
+			name: "short commit SHA",
+			ref:  func() string { return remote.commits["three"].String()[:7] },
+			want: []string{"one.txt", "three.txt", "two.txt"},
+		},
+		{
+			name:        "unknown ref",
+			ref:         func() string { return "does-not-exist" },
+			expectedErr: `ref "does-not-exist" not found`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, err := CloneGitRepository(remote.url, WithRef(tt.ref()))
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, repo)
+				return
+			}
+			require.NoError(t, err)
+
+			files, err := repo.GetFiles()
+			require.NoError(t, err)
+			sort.Strings(files)
+			assert.Equal(t, tt.want, files)
+		})
+	}
+}
+
+func TestRemoteRepository_FileSize(t *testing.T) {
+	t.Parallel()
+
+	remote := newTestRemote(t)
+	repo, err := CloneGitRepository(remote.url)
+	require.NoError(t, err)
+
+	size, err := repo.FileSize("two.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), size)
+
+	_, err = repo.FileSize("three.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = repo.GetFileContent("three.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestRemoteRepository_Context(t *testing.T) {
+	t.Parallel()
+
+	remote := newTestRemote(t)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CloneGitRepositoryContext(canceled, remote.url)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = CloneGitRepositoryContext(canceled, remote.url, WithRef("v1"))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	repo, err := CloneGitRepositoryContext(context.Background(), remote.url)
+	require.NoError(t, err)
+	_, err = repo.GetFilesContext(canceled)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetFileContentContext(canceled, "two.txt")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.ConcatFilesContext(canceled, []string{"two.txt"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRemoteRepository_WithRefHistory(t *testing.T) {
+	t.Parallel()
+
+	remote := newTestRemote(t)
+	cache := &CloneCache{Dir: t.TempDir()}
+	day := time.Unix(1700000000, 0).Format("2006-01-02")
+	line := func(name string) string {
+		return remote.commits[name].String()[:7] + "  " + day + "  gcat  " + name
+	}
+
+	tests := []struct {
+		name    string
+		ref     string
+		commit  string
+		history map[string]string
+	}{
+		{
+			name:    "full SHA of a commit behind the tip",
+			ref:     remote.commits["one"].String(),
+			commit:  "one",
+			history: map[string]string{"one.txt": line("one")},
+		},
+		{
+			name:    "short SHA of a commit on another branch",
+			ref:     remote.commits["three"].String()[:7],
+			commit:  "three",
+			history: map[string]string{"one.txt": line("one"), "three.txt": line("three"), "two.txt": line("two")},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, cached := range []bool{false, true} {
+			name := tt.name
+			opts := []Option{WithRef(tt.ref), WithHistory(HistoryOptions{Commits: 5}), WithFormatter(PlainFormatter{})}
+			if cached {
+				name += " cached"
+				opts = append(opts, WithCloneCache(cache))
+			}
+			t.Run(name, func(t *testing.T) {
+				repo, err := CloneGitRepository(remote.url, opts...)
+				require.NoError(t, err)
+				assert.Equal(t, remote.commits[tt.commit], repo.(*gitRepository).commit)
+
+				files, err := repo.GetFiles()
+				require.NoError(t, err)
+				sort.Strings(files)
+				got, err := repo.ConcatFiles(files)
+				require.NoError(t, err)
+				var want []string
+				for _, file := range files {
+					want = append(want, "==> "+file+" <==\n"+strings.TrimSuffix(file, ".txt")+"\n\n==> History of "+file+" <==\n"+tt.history[file])
+				}
+				assert.Equal(t, strings.Join(want, "\n\n"), got)
+				assert.Len(t, files, len(tt.history))
+			})
+		}
+	}
+}
+
+func TestFindRemoteRef(t *testing.T) {
+	t.Parallel()
+
+	remote := newTestRemote(t)
+	tests := []struct {
+		name string
+		ref  string
+		want plumbing.ReferenceName
+	}{
+		{name: "branch", ref: "feature", want: "refs/heads/feature"},
+		{name: "tag", ref: "v2", want: "refs/tags/v2"},
+		{name: "full SHA of a branch", ref: remote.commits["three"].String(), want: "refs/heads/feature"},
+		{name: "short SHA prefers the branch", ref: remote.commits["two"].String()[:7], want: "refs/heads/master"},
+		{name: "SHA of a lightweight tag", ref: strings.ToUpper(remote.commits["one"].String()[:10]), want: "refs/tags/v1"},
+		{name: "unknown", ref: "does-not-exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := findRemoteRef(context.Background(), remote.url, tt.ref, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := findRemoteRef(ctx, remote.url, "feature", nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRemoteRepository_WithRefSHA(t *testing.T) {
+	t.Parallel()
+
+	remote := newTestRemote(t)
+	clone := func(ref string) *gitRepository {
+		t.Helper()
+		repo, err := CloneGitRepository(remote.url, WithRef(ref))
+		require.NoError(t, err)
+		return repo.(*gitRepository)
+	}
+	shallow := func(g *gitRepository) bool {
+		t.Helper()
+		commits, err := g.repo.Storer.Shallow()
+		require.NoError(t, err)
+		return len(commits) > 0
+	}
+
+	tip := clone(remote.commits["three"].String()[:7])
+	assert.Equal(t, remote.commits["three"], tip.commit)
+	assert.True(t, shallow(tip), "the tip of a branch is cloned shallowly")
+
+	gitRepo, err := git.PlainOpen(remote.url)
+	require.NoError(t, err)
+	require.NoError(t, gitRepo.DeleteTag("v1"))
+	behind := clone(remote.commits["one"].String())
+	assert.Equal(t, remote.commits["one"], behind.commit)
+	assert.False(t, shallow(behind), "a commit behind every tip needs the full history")
+}
@@ -0,0 +1,119 @@
+package gcat
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// defaultSensitivePatterns name files that hold credentials as a whole, such
+// as private keys, package registry tokens and cluster configurations.
+var defaultSensitivePatterns = []string{
+	// SSH and TLS private keys, keystores and certificates bundled with keys.
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", "id_ecdsa_sk", "id_ed25519_sk",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore", "*.ppk",
+	// Credentials of package registries, hosts and services.
+	".npmrc", ".yarnrc.yml", ".pypirc", ".netrc", "_netrc", ".pgpass", ".git-credentials",
+	".htpasswd", ".dockercfg", ".docker/config.json",
+	"credentials.json", "client_secret*.json", ".aws/credentials", ".gcloud/credentials.db",
+	// Kubernetes configurations and Terraform state, which embed tokens.
+	"kubeconfig", "*.kubeconfig", ".kube/config", "*.tfstate", "*.tfstate.backup",
+}
+
+// DefaultSensitivePatterns returns the patterns of the files GetFiles leaves
+// out and ConcatFiles and ConcatTo refuse to write unless WithAllowSensitive
+// is given. See WithSensitivePatterns for their syntax.
+func DefaultSensitivePatterns() []string {
+	return append([]string(nil), defaultSensitivePatterns...)
+}
+
+// WithSensitivePatterns adds patterns to the sensitive files deny list.
+// Patterns use doublestar syntax. A pattern without a slash matches the name
+// of a file in any directory, such as "*.pem"; a pattern with one matches the
+// end of its slash-separated path, such as ".aws/credentials", or the whole
+// path when it starts with a slash.
+func WithSensitivePatterns(patterns ...string) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil {
+			rc.sensitive = append(rc.sensitive, patterns...)
+		}
+	}
+}
+
+// WithAllowSensitive lists and writes the files of the sensitive files deny
+// list like any other file.
+func WithAllowSensitive() Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil {
+			rc.allowSensitive = true
+		}
+	}
+}
+
+// SensitiveFileError is returned by ConcatFiles and ConcatTo when they are
+// asked to write a file of the sensitive files deny list.
+type SensitiveFileError struct {
+	Path string
+	// Pattern is the deny list pattern the file matches.
+	Pattern string
+}
+
+func (e *SensitiveFileError) Error() string {
+	return fmt.Sprintf("refusing to write sensitive file %s (matches %q)", e.Path, e.Pattern)
+}
+
+// IsSensitive reports whether filePath matches one of patterns, using the
+// syntax of WithSensitivePatterns, and which one.
+func IsSensitive(filePath string, patterns []string) (string, bool) {
+	slashed := filepath.ToSlash(filePath)
+	name := path.Base(slashed)
+	for _, pattern := range patterns {
+		var matched bool
+		switch {
+		case strings.HasPrefix(pattern, "/"):
+			matched = doublestar.MatchUnvalidated(pattern[1:], slashed)
+		case strings.Contains(pattern, "/"):
+			matched = doublestar.MatchUnvalidated("**/"+pattern, slashed)
+		default:
+			matched = doublestar.MatchUnvalidated(pattern, name)
+		}
+		if matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// denied reports whether filePath is on the deny list, and the pattern it
+// matches, unless sensitive files are allowed.
+func (rc *repoCommon) denied(filePath string) (string, bool) {
+	if rc.allowSensitive {
+		return "", false
+	}
+	return IsSensitive(filePath, rc.sensitive)
+}
+
+// dropSensitive removes the files of the deny list from files.
+func (rc *repoCommon) dropSensitive(files []string) []string {
+	kept := files[:0]
+	for _, filePath := range files {
+		if _, sensitive := rc.denied(filePath); !sensitive {
+			kept = append(kept, filePath)
+		}
+	}
+	return kept
+}
+
+// checkSensitive returns a *SensitiveFileError for the first file of files
+// on the deny list.
+func (rc *repoCommon) checkSensitive(files []string) error {
+	for _, filePath := range files {
+		if pattern, sensitive := rc.denied(filePath); sensitive {
+			return &SensitiveFileError{Path: filePath, Pattern: pattern}
+		}
+	}
+	return nil
+}
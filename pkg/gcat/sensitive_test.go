@@ -0,0 +1,170 @@
+package gcat
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSensitive(t *testing.T) {
+	t.Parallel()
+
+	patterns := DefaultSensitivePatterns()
+	tests := []struct {
+		filePath string
+		want     bool
+	}{
+		{filePath: "id_rsa", want: true},
+		{filePath: ".ssh/id_ed25519", want: true},
+		{filePath: "deploy/keys/id_ecdsa", want: true},
+		{filePath: "certs/server.pem", want: true},
+		{filePath: "tls/server.key", want: true},
+		{filePath: "signing/release.p12", want: true},
+		{filePath: "client.pfx", want: true},
+		{filePath: "android/app/release.jks", want: true},
+		{filePath: "putty.ppk", want: true},
+		{filePath: ".npmrc", want: true},
+		{filePath: "frontend/.npmrc", want: true},
+		{filePath: ".pypirc", want: true},
+		{filePath: ".netrc", want: true},
+		{filePath: ".pgpass", want: true},
+		{filePath: ".git-credentials", want: true},
+		{filePath: ".docker/config.json", want: true},
+		{filePath: "credentials.json", want: true},
+		{filePath: "config/client_secret_1234.apps.googleusercontent.com.json", want: true},
+		{filePath: ".aws/credentials", want: true},
+		{filePath: "home/.aws/credentials", want: true},
+		{filePath: "kubeconfig", want: true},
+		{filePath: "clusters/prod.kubeconfig", want: true},
+		{filePath: ".kube/config", want: true},
+		{filePath: "infra/terraform.tfstate", want: true},
+		{filePath: filepath.Join("nested", "dir", "id_rsa"), want: true},
+
+		{filePath: "id_rsa.pub", want: false},
+		{filePath: "main.go", want: false},
+		{filePath: "config.json", want: false},
+		{filePath: "credentials.go", want: false},
+		{filePath: "docs/keys.md", want: false},
+		{filePath: ".env.example", want: false},
+		{filePath: "pkg/credentials/store.go", want: false},
+		{filePath: "kube/config.yaml", want: false},
+		{filePath: "package.json", want: false},
+	}
+	for _, tt := range tests {
+		_, got := IsSensitive(tt.filePath, patterns)
+		assert.Equal(t, tt.want, got, tt.filePath)
+	}
+
+	pattern, ok := IsSensitive("secrets/prod.yaml", []string{"/secrets/*.yaml"})
+	assert.True(t, ok)
+	assert.Equal(t, "/secrets/*.yaml", pattern)
+	_, ok = IsSensitive("app/secrets/prod.yaml", []string{"/secrets/*.yaml"})
+	assert.False(t, ok)
+}
+
+func TestLocalRepository_SensitiveFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":         "package main\n",
+		"id_rsa":          "private\n",
+		"certs/tls.pem":   "certificate\n",
+		"web/.npmrc":      "//registry.npmjs.org/:_authToken=secret\n",
+		"secrets/db.yaml": "password: secret\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantFiles []string
+	}{
+		{
+			name:      "default deny list",
+			wantFiles: []string{"main.go", filepath.Join("secrets", "db.yaml")},
+		},
+		{
+			name:      "custom patterns",
+			opts:      []Option{WithSensitivePatterns("secrets/**")},
+			wantFiles: []string{"main.go"},
+		},
+		{
+			name: "allowed",
+			opts: []Option{WithAllowSensitive()},
+			wantFiles: []string{
+				filepath.Join("certs", "tls.pem"), "id_rsa", "main.go",
+				filepath.Join("secrets", "db.yaml"), filepath.Join("web", ".npmrc"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, err := NewLocalRepository(dir, append([]Option{WithFormatter(PlainFormatter{})}, tt.opts...)...)
+			require.NoError(t, err)
+			files, err := repo.GetFiles()
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.wantFiles, files)
+		})
+	}
+
+	t.Run("explicitly selected", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := NewLocalRepository(dir, WithFormatter(PlainFormatter{}))
+		require.NoError(t, err)
+		got, err := repo.ConcatFiles([]string{"main.go", "id_rsa"})
+		var sensitiveErr *SensitiveFileError
+		require.True(t, errors.As(err, &sensitiveErr), "got %v", err)
+		assert.Equal(t, "id_rsa", sensitiveErr.Path)
+		assert.Equal(t, "id_rsa", sensitiveErr.Pattern)
+		assert.Empty(t, got)
+
+		allowed, err := NewLocalRepository(dir, WithFormatter(PlainFormatter{}), WithAllowSensitive())
+		require.NoError(t, err)
+		got, err = allowed.ConcatFiles([]string{"id_rsa"})
+		require.NoError(t, err)
+		assert.Equal(t, "==> id_rsa <==\nprivate\n", got)
+	})
+}
+
+func TestRemoteRepository_SensitiveFiles(t *testing.T) {
+	t.Parallel()
+
+	remote := newTestRemote(t)
+	repo, err := CloneGitRepository(remote.url, WithSensitivePatterns("two.txt"))
+	require.NoError(t, err)
+
+	files, err := repo.GetFiles()
+	require.NoError(t, err)
+	assert.NotContains(t, files, "two.txt")
+
+	var sensitiveErr *SensitiveFileError
+	_, err = repo.ConcatFiles([]string{"two.txt"})
+	assert.True(t, errors.As(err, &sensitiveErr), "got %v", err)
+}
+
+func TestDiff_SensitiveFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := newDiffWorktree(t)
+	d, err := OpenDiff(dir, "HEAD~1", "HEAD", WithSensitivePatterns("c.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "a.txt", Status: ChangeModified},
+		{Path: filepath.Join("sub", "d.txt"), Status: ChangeAdded},
+	}, d.Changes())
+
+	var sensitiveErr *SensitiveFileError
+	_, err = d.ConcatFiles([]Change{{Path: "c.txt", Status: ChangeDeleted}}, DiffPatch)
+	assert.True(t, errors.As(err, &sensitiveErr), "got %v", err)
+}
@@ -0,0 +1,139 @@
+package gcat
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/timsexperiments/gcat/pkg/tokenizer"
+)
+
+// FileTokens is the number of tokens in a single file's entry of the output.
+type FileTokens struct {
+	Path   string
+	Tokens int
+}
+
+// TokenCounts are the token counts of the files given to CountTokens, along
+// with their rendered entries, so that they can be written without reading
+// the files again.
+type TokenCounts struct {
+	// Files are the counts in sorted path order, the order ConcatTo writes the
+	// files in.
+	Files []FileTokens
+	Total int
+
+	concat func(ctx context.Context, w io.Writer, n int) error
+}
+
+// CountTokens renders files in memory as ConcatTo writes them, outlined,
+// redacted, limited in size and with binary files rendered by the binary
+// policy, and counts the tokens in each file's entry with tok. Binary files
+// the policy leaves out are not counted. Since every entry is held in memory
+// until Concat writes it, the output is not streamed.
+//
+// Repositories other than the built-in ones are counted on the contents
+// GetFileContent returns instead.
+func CountTokens(ctx context.Context, repo Repository, files []string, tok tokenizer.Tokenizer) (*TokenCounts, error) {
+	files = slices.Clone(files)
+	sort.Strings(files)
+
+	rc := commonOf(repo)
+	if rc == nil {
+		return countContents(ctx, repo, files, tok)
+	}
+	if err := rc.checkSensitive(files); err != nil {
+		return nil, err
+	}
+	var open openFunc
+	switch r := repo.(type) {
+	case *localRepository:
+		open = r.open
+	case *gitRepository:
+		open = r.open
+	}
+
+	var paths []string
+	var entries []*bytes.Buffer
+	err := rc.writeEntries(ctx, files, open, func(filePath string) io.Writer {
+		paths = append(paths, filePath)
+		entries = append(entries, new(bytes.Buffer))
+		return entries[len(entries)-1]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &TokenCounts{Files: make([]FileTokens, 0, len(entries))}
+	var written []string
+	var kept []*bytes.Buffer
+	for i, entry := range entries {
+		if entry.Len() == 0 {
+			// Left out by the binary policy.
+			continue
+		}
+		n := tok.Count(entry.String())
+		counts.Files = append(counts.Files, FileTokens{Path: paths[i], Tokens: n})
+		counts.Total += n
+		written = append(written, paths[i])
+		kept = append(kept, entry)
+	}
+	counts.concat = func(ctx context.Context, w io.Writer, n int) error {
+		if err := rc.begin(ctx, w, written[:n]); err != nil {
+			return err
+		}
+		for _, entry := range kept[:n] {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, err := w.Write(entry.Bytes()); err != nil {
+				return err
+			}
+		}
+		return rc.formatter.End(w)
+	}
+	return counts, nil
+}
+
+// countContents counts the tokens in the contents of each of files, sorted,
+// of a repository other than the built-in ones.
+func countContents(ctx context.Context, repo Repository, files []string, tok tokenizer.Tokenizer) (*TokenCounts, error) {
+	counts := &TokenCounts{Files: make([]FileTokens, 0, len(files))}
+	for _, filePath := range files {
+		content, err := repo.GetFileContentContext(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+		n := tok.Count(content)
+		counts.Files = append(counts.Files, FileTokens{Path: filePath, Tokens: n})
+		counts.Total += n
+	}
+	counts.concat = func(ctx context.Context, w io.Writer, n int) error {
+		return repo.ConcatTo(ctx, w, files[:n])
+	}
+	return counts, nil
+}
+
+// Concat writes the files of kept, the leading counts of Files such as the
+// ones FitTokenBudget keeps, as ConcatTo would, from their rendered entries.
+func (c *TokenCounts) Concat(ctx context.Context, w io.Writer, kept []FileTokens) error {
+	return c.concat(ctx, w, min(len(kept), len(c.Files)))
+}
+
+// FitTokenBudget splits counts into the longest leading run of files whose
+// combined tokens do not exceed maxTokens and the files that follow it.
+//
+// Because counts are in output order, the result is deterministic: the same
+// selection and budget always keep the same files.
+func FitTokenBudget(counts []FileTokens, maxTokens int) (kept, dropped []FileTokens) {
+	total := 0
+	for i, count := range counts {
+		if total+count.Tokens > maxTokens {
+			return counts[:i], counts[i:]
+		}
+		total += count.Tokens
+	}
+	return counts, nil
+}
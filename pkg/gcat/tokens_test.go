@@ -0,0 +1,178 @@
+package gcat
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timsexperiments/gcat/pkg/redact"
+	"github.com/timsexperiments/gcat/pkg/tokenizer"
+)
+
+// byteTokenizer counts one token per byte, so that counts add up exactly.
+type byteTokenizer struct{}
+
+func (byteTokenizer) Name() string { return "bytes" }
+
+func (byteTokenizer) Count(text string) int { return len(text) }
+
+func TestCountTokens(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.txt":     "hello\n",
+		"b.env":     "API_KEY=abcdefghijklmnopqrstuvwxyz012345\n",
+		"c.png":     "\x89PNG\r\n\x1a\n\x00\x00",
+		"d.txt":     strings.Repeat("long line\n", 20),
+		"e/f.txt":   "nested\n",
+		"skipped.x": "not selected\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	files := []string{"d.txt", "a.txt", "b.env", "c.png", filepath.Join("e", "f.txt")}
+
+	tests := []struct {
+		name string
+		opts []Option
+		// withTree is set when the output has a tree, which is not counted.
+		withTree bool
+	}{
+		{name: "defaults"},
+		{name: "redaction", opts: []Option{WithRedactor(redact.New())}},
+		{name: "total size limit", opts: []Option{WithMaxTotalSize(100)}},
+		{name: "binary placeholder and tree", opts: []Option{WithBinaryPolicy(BinaryPlaceholder), WithTree(TreeOptions{})}, withTree: true},
+		{name: "sequential", opts: []Option{WithConcurrency(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, err := NewLocalRepository(dir, append([]Option{WithFormatter(PlainFormatter{})}, tt.opts...)...)
+			require.NoError(t, err)
+			want, err := repo.ConcatFiles(files)
+			require.NoError(t, err)
+
+			counts, err := CountTokens(context.Background(), repo, files, byteTokenizer{})
+			require.NoError(t, err)
+			var got strings.Builder
+			require.NoError(t, counts.Concat(context.Background(), &got, counts.Files))
+			assert.Equal(t, want, got.String(), "written from the counted entries")
+
+			total := 0
+			for _, count := range counts.Files {
+				total += count.Tokens
+			}
+			assert.Equal(t, counts.Total, total)
+			if !tt.withTree {
+				assert.Equal(t, len(want), counts.Total, "counts the whole output")
+			}
+		})
+	}
+
+	t.Run("counts what is written", func(t *testing.T) {
+		t.Parallel()
+
+		count := func(opts ...Option) *TokenCounts {
+			repo, err := NewLocalRepository(dir, append([]Option{WithFormatter(PlainFormatter{})}, opts...)...)
+			require.NoError(t, err)
+			counts, err := CountTokens(context.Background(), repo, files, byteTokenizer{})
+			require.NoError(t, err)
+			return counts
+		}
+		raw := count()
+		counts := count(WithRedactor(redact.New()), WithMaxTotalSize(100))
+
+		paths := make([]string, len(counts.Files))
+		for i, count := range counts.Files {
+			paths[i] = count.Path
+		}
+		assert.Equal(t, []string{"a.txt", "b.env", "d.txt", filepath.Join("e", "f.txt")}, paths, "skipped binary files are not counted")
+		assert.Equal(t, raw.Files[0], counts.Files[0])
+		assert.NotEqual(t, raw.Files[1], counts.Files[1], "redacted")
+		assert.Less(t, counts.Files[2].Tokens, raw.Files[2].Tokens, "truncated")
+	})
+
+	t.Run("keeps a leading run", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := NewLocalRepository(dir, WithFormatter(PlainFormatter{}))
+		require.NoError(t, err)
+		counts, err := CountTokens(context.Background(), repo, files, tokenizer.Heuristic{})
+		require.NoError(t, err)
+		kept, _ := FitTokenBudget(counts.Files, counts.Files[0].Tokens+counts.Files[1].Tokens)
+		require.Len(t, kept, 2)
+
+		var got strings.Builder
+		require.NoError(t, counts.Concat(context.Background(), &got, kept))
+		want, err := repo.ConcatFiles([]string{"a.txt", "b.env"})
+		require.NoError(t, err)
+		assert.Equal(t, want, got.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := NewLocalRepository(dir)
+		require.NoError(t, err)
+		_, err = CountTokens(context.Background(), repo, []string{"non-existent.txt"}, tokenizer.Heuristic{})
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+
+		canceled, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = CountTokens(canceled, repo, files, tokenizer.Heuristic{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestFitTokenBudget(t *testing.T) {
+	t.Parallel()
+
+	counts := []FileTokens{
+		{Path: "a.go", Tokens: 10},
+		{Path: "b.go", Tokens: 20},
+		{Path: "c.go", Tokens: 5},
+	}
+
+	tests := []struct {
+		name        string
+		maxTokens   int
+		wantKept    []FileTokens
+		wantDropped []FileTokens
+	}{
+		{
+			name:      "everything fits",
+			maxTokens: 35,
+			wantKept:  counts,
+		},
+		{
+			name:        "drops the files after the budget is reached",
+			maxTokens:   29,
+			wantKept:    counts[:1],
+			wantDropped: counts[1:],
+		},
+		{
+			name:        "nothing fits",
+			maxTokens:   9,
+			wantKept:    counts[:0],
+			wantDropped: counts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			kept, dropped := FitTokenBudget(counts, tt.maxTokens)
+			assert.Equal(t, tt.wantKept, kept)
+			assert.Equal(t, tt.wantDropped, dropped)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package gcat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TreeOptions configure the directory tree written before the files by
+// WithTree.
+type TreeOptions struct {
+	// SelectedOnly limits the tree to the concatenated files instead of all
+	// the files of the repository.
+	SelectedOnly bool
+	// MaxDepth is the number of directory levels shown; deeper directories
+	// are summarized. Zero means no limit.
+	MaxDepth int
+	// Collapse summarizes directories holding more than this many files
+	// when none of them are included. Zero means no limit.
+	Collapse int
+}
+
+// TreeTitle is the title of the directory tree section.
+const TreeTitle = "Directory tree"
+
+// WithTree writes a directory tree of the repository's files before the
+// concatenated files, marking the files that are included.
+func WithTree(opts TreeOptions) Option {
+	return func(r Repository) {
+		if rc := commonOf(r); rc != nil {
+			rc.tree = &opts
+		}
+	}
+}
+
+// writeTree writes the directory tree section as the entry at index, marking
+// files as included.
+func (rc *repoCommon) writeTree(ctx context.Context, w io.Writer, index int, files []string) error {
+	all := files
+	if !rc.tree.SelectedOnly {
+		var err error
+		if all, err = rc.getFiles(ctx); err != nil {
+			return err
+		}
+	}
+	return rc.writeSection(w, index, TreeTitle, RenderTree(all, files, *rc.tree))
+}
+
+// treeDir is a directory of the tree rendered by RenderTree.
+type treeDir struct {
+	name     string
+	dirs     map[string]*treeDir
+	files    []string
+	total    int
+	included int
+}
+
+// RenderTree draws files as an ASCII directory tree like tree(1), marking
+// the included files with "*". Directories are listed before files.
+// Directories below opts.MaxDepth, and directories with more than
+// opts.Collapse files none of which are included, are shown with their file
+// counts instead of their contents.
+func RenderTree(files, included []string, opts TreeOptions) string {
+	isIncluded := make(map[string]bool, len(included))
+	for _, f := range included {
+		isIncluded[filepath.ToSlash(f)] = true
+	}
+
+	root := &treeDir{name: ".", dirs: map[string]*treeDir{}}
+	for _, f := range files {
+		f = filepath.ToSlash(f)
+		parts := strings.Split(f, "/")
+		dir := root
+		dir.total++
+		if isIncluded[f] {
+			dir.included++
+		}
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := dir.dirs[part]
+			if !ok {
+				child = &treeDir{name: part, dirs: map[string]*treeDir{}}
+				dir.dirs[part] = child
+			}
+			dir = child
+			dir.total++
+			if isIncluded[f] {
+				dir.included++
+			}
+		}
+		name := path.Base(f)
+		if isIncluded[f] {
+			name += " *"
+		}
+		dir.files = append(dir.files, name)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(".\n")
+	root.render(&sb, "", 1, opts)
+	fmt.Fprintf(&sb, "\n%s, %s included (*)", plural(root.total, "file"), plural(root.included, "file"))
+	return sb.String()
+}
+
+func (d *treeDir) render(sb *strings.Builder, prefix string, depth int, opts TreeOptions) {
+	names := make([]string, 0, len(d.dirs))
+	for name := range d.dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sort.Strings(d.files)
+
+	entries := len(names) + len(d.files)
+	for i, name := range names {
+		child := d.dirs[name]
+		branch, indent := "├── ", "│   "
+		if i == entries-1 {
+			branch, indent = "└── ", "    "
+		}
+		deep := opts.MaxDepth > 0 && depth >= opts.MaxDepth
+		large := opts.Collapse > 0 && child.total > opts.Collapse && child.included == 0
+		if deep || large {
+			fmt.Fprintf(sb, "%s%s%s/ (%s)\n", prefix, branch, name, child.summary())
+			continue
+		}
+		fmt.Fprintf(sb, "%s%s%s/\n", prefix, branch, name)
+		child.render(sb, prefix+indent, depth+1, opts)
+	}
+	for i, name := range d.files {
+		branch := "├── "
+		if len(names)+i == entries-1 {
+			branch = "└── "
+		}
+		fmt.Fprintf(sb, "%s%s%s\n", prefix, branch, name)
+	}
+}
+
+// summary describes a directory whose contents are not shown.
+func (d *treeDir) summary() string {
+	if d.included == 0 {
+		return plural(d.total, "file")
+	}
+	return fmt.Sprintf("%s, %d included", plural(d.total, "file"), d.included)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,172 @@
+package gcat
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var treeTestFiles = []string{
+	"README.md",
+	"cmd/gcat/main.go",
+	"go.mod",
+	"pkg/gcat/gcat.go",
+	"pkg/gcat/gcat_test.go",
+	"pkg/gcat/testdata/a.txt",
+	"pkg/gcat/testdata/b.txt",
+	"pkg/gcat/testdata/c.txt",
+	"pkg/lang/lang.go",
+}
+
+func TestRenderTree(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		included []string
+		opts     TreeOptions
+		want     string
+	}{
+		{
+			name:     "full tree",
+			included: []string{"cmd/gcat/main.go", "pkg/gcat/gcat.go"},
+			want: `.
+├── cmd/
+│   └── gcat/
+│       └── main.go *
+├── pkg/
+│   ├── gcat/
+│   │   ├── testdata/
+│   │   │   ├── a.txt
+│   │   │   ├── b.txt
+│   │   │   └── c.txt
+│   │   ├── gcat.go *
+│   │   └── gcat_test.go
+│   └── lang/
+│       └── lang.go
+├── README.md
+└── go.mod
+
+9 files, 2 files included (*)`,
+		},
+		{
+			name:     "depth limit",
+			included: []string{"README.md", "pkg/gcat/gcat.go"},
+			opts:     TreeOptions{MaxDepth: 1},
+			want: `.
+├── cmd/ (1 file)
+├── pkg/ (6 files, 1 included)
+├── README.md *
+└── go.mod
+
+9 files, 2 files included (*)`,
+		},
+		{
+			name:     "collapse directories without included files",
+			included: []string{"pkg/gcat/gcat.go"},
+			opts:     TreeOptions{Collapse: 2},
+			want: `.
+├── cmd/
+│   └── gcat/
+│       └── main.go
+├── pkg/
+│   ├── gcat/
+│   │   ├── testdata/ (3 files)
+│   │   ├── gcat.go *
+│   │   └── gcat_test.go
+│   └── lang/
+│       └── lang.go
+├── README.md
+└── go.mod
+
+9 files, 1 file included (*)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, RenderTree(treeTestFiles, tt.included, tt.opts))
+		})
+	}
+}
+
+func TestWithTree(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "docs/guide.md", "docs/api.md"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644))
+	}
+
+	t.Run("default format", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := NewLocalRepository(dir, WithTree(TreeOptions{}))
+		require.NoError(t, err)
+		got, err := repo.ConcatFiles([]string{"a.go"})
+		require.NoError(t, err)
+		assert.Equal(t, `Directory tree:
+
+<contents>
+.
+├── docs/
+│   ├── api.md
+│   └── guide.md
+└── a.go *
+
+3 files, 1 file included (*)
+</contents>
+
+---
+
+a.go (Go):
+
+<contents>
+a.go
+</contents>`, got)
+	})
+
+	t.Run("selected files only", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := NewLocalRepository(dir, WithTree(TreeOptions{SelectedOnly: true}), WithFormatter(PlainFormatter{}))
+		require.NoError(t, err)
+		got, err := repo.ConcatFiles([]string{"docs/api.md"})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(got, "==> Directory tree <==\n.\n└── docs/\n    └── api.md *\n\n1 file, 1 file included (*)\n\n==> docs/api.md <=="), got)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := NewLocalRepository(dir, WithTree(TreeOptions{}), WithFormatter(JSONFormatter{}))
+		require.NoError(t, err)
+		got, err := repo.ConcatFiles([]string{"a.go", "docs/api.md"})
+		require.NoError(t, err)
+
+		var entries []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(got), &entries))
+		require.Len(t, entries, 3)
+		assert.Equal(t, TreeTitle, entries[0]["section"])
+		assert.Contains(t, entries[0]["content"], "api.md *")
+		assert.Equal(t, "a.go", entries[1]["path"])
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		t.Parallel()
+
+		repo, err := NewLocalRepository(dir, WithTree(TreeOptions{MaxDepth: 1}), WithFormatter(MarkdownFormatter{}))
+		require.NoError(t, err)
+		got, err := repo.ConcatFiles([]string{"a.go"})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(got, "## Directory tree\n\n```text\n.\n├── docs/ (2 files)\n└── a.go *\n"), got)
+	})
+}
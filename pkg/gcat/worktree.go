@@ -0,0 +1,243 @@
+package gcat
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/timsexperiments/gcat/internal/gitignore"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	gitconfig "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// gitFiles lists the files of the Git working tree containing l.root the way
+// "git ls-files" does: every path tracked in the index, plus untracked files
+// that are not ignored when l.untracked is set. Tracked files that have been
+// deleted from the working tree are skipped since they can no longer be read.
+// Paths are relative to l.root. It stops once ctx is done.
+func (l *localRepository) gitFiles(ctx context.Context) ([]string, error) {
+	repo, err := git.PlainOpenWithOptions(l.root, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening Git repository at %s: %w", l.root, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := worktreePrefix(wt.Filesystem.Root(), l.root)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	tracked := make(map[string]bool, len(idx.Entries))
+	var files []string
+	for _, entry := range idx.Entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if tracked[entry.Name] || entry.Mode == filemode.Submodule {
+			continue
+		}
+		tracked[entry.Name] = true
+		rel, ok := strings.CutPrefix(entry.Name, prefix)
+		if !ok {
+			continue
+		}
+		rel = filepath.FromSlash(rel)
+		if _, err := os.Lstat(filepath.Join(l.root, rel)); err != nil {
+			continue
+		}
+		files = append(files, rel)
+	}
+
+	if l.untracked {
+		var dotGit string
+		if storage, ok := repo.Storer.(*filesystem.Storage); ok {
+			dotGit = storage.Filesystem().Root()
+		}
+		rules, err := loadExcludeRules(wt.Filesystem.Root(), dotGit, prefix)
+		if err != nil {
+			return nil, err
+		}
+		untracked, err := l.untrackedFiles(ctx, prefix, tracked, rules)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, untracked...)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// untrackedFiles walks l.root for files that are neither tracked nor ignored.
+// Nested repositories are skipped, as git does.
+func (l *localRepository) untrackedFiles(ctx context.Context, prefix string, tracked map[string]bool, rules *gitignore.Stack) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(l.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		repoPath := prefix + filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if d.Name() == ".git" || rules.Match(repoPath, true) {
+				return fs.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+				return fs.SkipDir
+			}
+			return rules.PushFile(repoPath, path)
+		}
+		if !tracked[repoPath] && !rules.Match(repoPath, false) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// worktreePrefix returns the slash-separated path of dir inside the working
+// tree rooted at top, with a trailing slash, or "" when dir is top.
+func worktreePrefix(top, dir string) (string, error) {
+	resolve := func(path string) (string, error) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		return filepath.EvalSymlinks(abs)
+	}
+	top, err := resolve(top)
+	if err != nil {
+		return "", err
+	}
+	dir, err = resolve(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(top, dir)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
+
+// loadExcludeRules loads the ignore rules git applies to untracked files in
+// ascending order of priority: the global excludes file, the repository's
+// info/exclude and the .gitignore files of the working tree rooted at top.
+// Only the .gitignore files from top down to prefix are read here; the walk
+// pushes the rest as it visits each directory.
+func loadExcludeRules(top, dotGit, prefix string) (*gitignore.Stack, error) {
+	var base []gitignore.Pattern
+	var excludeFiles []string
+	if global := globalExcludesFile(); global != "" {
+		excludeFiles = append(excludeFiles, global)
+	}
+	if dotGit != "" {
+		excludeFiles = append(excludeFiles, filepath.Join(dotGit, "info", "exclude"))
+	}
+	for _, file := range excludeFiles {
+		patterns, err := readExcludeFile(file)
+		if err != nil {
+			return nil, err
+		}
+		base = append(base, patterns...)
+	}
+
+	rules := gitignore.NewStack(base)
+	if err := rules.PushFile("", top); err != nil {
+		return nil, err
+	}
+	dir := ""
+	for _, name := range strings.Split(strings.TrimSuffix(prefix, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		dir = path.Join(dir, name)
+		if err := rules.PushFile(dir, filepath.Join(top, filepath.FromSlash(dir))); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// globalExcludesFile returns the path of the user's global excludes file the
+// way git finds it: core.excludesFile from $GIT_CONFIG_GLOBAL when it is set,
+// or else from ~/.gitconfig or $XDG_CONFIG_HOME/git/config, in that order of
+// priority, and otherwise git's default of $XDG_CONFIG_HOME/git/ignore.
+func globalExcludesFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	configs := []string{filepath.Join(home, ".gitconfig"), filepath.Join(configHome, "git", "config")}
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		configs = []string{global}
+	}
+	for _, config := range configs {
+		if path := excludesFileOption(config); path != "" {
+			if rest, ok := strings.CutPrefix(path, "~/"); ok {
+				path = filepath.Join(home, rest)
+			}
+			return path
+		}
+	}
+	return filepath.Join(configHome, "git", "ignore")
+}
+
+// excludesFileOption returns core.excludesFile from the git config file at
+// path, or "" if the file does not set it or cannot be read.
+func excludesFileOption(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	cfg := gitconfig.New()
+	if err := gitconfig.NewDecoder(strings.NewReader(string(data))).Decode(cfg); err != nil {
+		return ""
+	}
+	return cfg.Section("core").Option("excludesfile")
+}
+
+// readExcludeFile parses a file of repository-wide ignore patterns. A missing
+// file has no patterns.
+func readExcludeFile(path string) ([]gitignore.Pattern, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	return gitignore.Parse(file, "")
+}
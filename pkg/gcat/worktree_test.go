@@ -0,0 +1,163 @@
+package gcat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// newTestWorktree creates a Git working tree with tracked, untracked, ignored
+// and deleted files.
+func newTestWorktree(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	tracked := []string{".gitignore", "tracked.txt", "sub/tracked.go", "sub/forced.log", "deleted.txt"}
+	write(".gitignore", "*.log\n")
+	write("tracked.txt", "tracked")
+	write("sub/tracked.go", "package sub")
+	write("sub/forced.log", "tracked even though it is ignored")
+	write("deleted.txt", "deleted")
+	for _, name := range tracked {
+		_, err := wt.Add(name)
+		require.NoError(t, err)
+	}
+	_, err = wt.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "gcat", Email: "gcat@example.com", When: time.Unix(1700000000, 0)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, "deleted.txt")))
+
+	write("untracked.txt", "untracked")
+	write("sub/untracked.go", "package sub")
+	write("debug.log", "ignored by .gitignore")
+	write("secret.env", "ignored by info/exclude")
+	write("scratch.tmp", "ignored by the global excludes file")
+	write("build/out.bin", "ignored directory")
+	write(".git/info/exclude", "# local excludes\nsecret.env\n")
+	write("sub/.gitignore", "/build/\n")
+	write("sub/build/gen.go", "ignored by a nested .gitignore")
+	write("nested/.git/HEAD", "nested repositories are skipped")
+	write("nested/file.txt", "inside a nested repository")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\nbuild/\n"), 0o644))
+
+	return dir
+}
+
+func TestLocalRepository_WithGitIndex(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "git", "ignore"), []byte("*.tmp\n"), 0o644))
+
+	dir := newTestWorktree(t)
+
+	tests := []struct {
+		name        string
+		root        string
+		untracked   bool
+		want        []string
+		expectedErr string
+	}{
+		{
+			name: "tracked files only",
+			root: dir,
+			want: []string{".gitignore", "sub/forced.log", "sub/tracked.go", "tracked.txt"},
+		},
+		{
+			name:      "tracked and untracked files",
+			root:      dir,
+			untracked: true,
+			want: []string{
+				".gitignore",
+				"sub/.gitignore",
+				"sub/forced.log",
+				"sub/tracked.go",
+				"sub/untracked.go",
+				"tracked.txt",
+				"untracked.txt",
+			},
+		},
+		{
+			name:      "subdirectory of the working tree",
+			root:      filepath.Join(dir, "sub"),
+			untracked: true,
+			want:      []string{".gitignore", "forced.log", "tracked.go", "untracked.go"},
+		},
+		{
+			name:        "not a Git repository",
+			root:        t.TempDir(),
+			expectedErr: "opening Git repository",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := NewLocalRepository(tt.root, WithGitIndex(tt.untracked))
+			require.NoError(t, err)
+
+			files, err := repo.GetFiles()
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			want := make([]string, len(tt.want))
+			for i, name := range tt.want {
+				want[i] = filepath.FromSlash(name)
+			}
+			assert.Equal(t, want, files)
+		})
+	}
+}
+
+func TestGlobalExcludesFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", "")
+	writeConfig := func(path, excludesFile string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		config := "[user]\n\tname = gcat\n[core]\n\texcludesFile = " + excludesFile + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(config), 0o644))
+	}
+
+	assert.Equal(t, filepath.Join(home, ".config", "git", "ignore"), globalExcludesFile())
+
+	xdg := filepath.Join(home, "xdg")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	assert.Equal(t, filepath.Join(xdg, "git", "ignore"), globalExcludesFile())
+
+	writeConfig(filepath.Join(xdg, "git", "config"), "~/.xdg_ignore")
+	assert.Equal(t, filepath.Join(home, ".xdg_ignore"), globalExcludesFile(), "$XDG_CONFIG_HOME/git/config")
+
+	writeConfig(filepath.Join(home, ".gitconfig"), "~/.gitignore_global")
+	assert.Equal(t, filepath.Join(home, ".gitignore_global"), globalExcludesFile(), "~/.gitconfig takes priority")
+
+	custom := filepath.Join(home, "custom.gitconfig")
+	t.Setenv("GIT_CONFIG_GLOBAL", custom)
+	assert.Equal(t, filepath.Join(xdg, "git", "ignore"), globalExcludesFile(), "$GIT_CONFIG_GLOBAL replaces the other files")
+
+	writeConfig(custom, "/etc/gcat/ignore")
+	assert.Equal(t, "/etc/gcat/ignore", globalExcludesFile())
+}
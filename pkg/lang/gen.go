@@ -0,0 +1,148 @@
+//go:build ignore
+
+// gen.go generates languages_gen.go from languages.yml. Run it with
+// "go generate ./pkg/lang".
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type entry struct {
+	Type         string   `yaml:"type"`
+	Aliases      []string `yaml:"aliases"`
+	Extensions   []string `yaml:"extensions"`
+	Filenames    []string `yaml:"filenames"`
+	Interpreters []string `yaml:"interpreters"`
+	Fence        string   `yaml:"fence"`
+}
+
+var (
+	validTypes = map[string]string{
+		"programming": "Programming",
+		"markup":      "Markup",
+		"data":        "Data",
+		"prose":       "Prose",
+	}
+	fenceName = regexp.MustCompile(`^[a-z0-9_+-]+$`)
+)
+
+func main() {
+	data, err := os.ReadFile("languages.yml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var entries map[string]entry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		log.Fatalf("parsing languages.yml: %v", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	if err := validate(names, entries); err != nil {
+		log.Fatalf("languages.yml: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen.go from languages.yml; DO NOT EDIT.\n\n")
+	buf.WriteString("package lang\n\n")
+	buf.WriteString("var languages = []Language{\n")
+	for _, name := range names {
+		e := entries[name]
+		fmt.Fprintf(&buf, "\t{\n\t\tName: %q,\n\t\tType: %s,\n", name, validTypes[e.Type])
+		writeList(&buf, "Aliases", e.Aliases)
+		writeList(&buf, "Extensions", e.Extensions)
+		writeList(&buf, "Filenames", e.Filenames)
+		writeList(&buf, "Interpreters", e.Interpreters)
+		fmt.Fprintf(&buf, "\t\tFence: %q,\n\t},\n", fence(name, e))
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("formatting generated code: %v", err)
+	}
+	if err := os.WriteFile("languages_gen.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// validate checks that every entry is well formed and that no name or alias
+// refers to two languages.
+func validate(names []string, entries map[string]entry) error {
+	seen := map[string]string{}
+	claim := func(key, name string) error {
+		key = strings.ToLower(key)
+		if other, ok := seen[key]; ok && other != name {
+			return fmt.Errorf("%q is used by both %s and %s", key, other, name)
+		}
+		seen[key] = name
+		return nil
+	}
+
+	for _, name := range names {
+		e := entries[name]
+		if _, ok := validTypes[e.Type]; !ok {
+			return fmt.Errorf("%s: invalid type %q", name, e.Type)
+		}
+		if err := claim(name, name); err != nil {
+			return err
+		}
+		for _, alias := range e.Aliases {
+			if err := claim(alias, name); err != nil {
+				return err
+			}
+		}
+		for _, ext := range e.Extensions {
+			if !strings.HasPrefix(ext, ".") || ext != strings.ToLower(ext) {
+				return fmt.Errorf("%s: extension %q must be lower case and start with a dot", name, ext)
+			}
+		}
+		for _, interpreter := range e.Interpreters {
+			if interpreter != strings.ToLower(interpreter) {
+				return fmt.Errorf("%s: interpreter %q must be lower case", name, interpreter)
+			}
+		}
+	}
+	return nil
+}
+
+// fence returns the Markdown fence identifier of a language: the explicit
+// one, else the lower-cased name when it is a single word, else the first
+// alias.
+func fence(name string, e entry) string {
+	if e.Fence != "" {
+		return e.Fence
+	}
+	lower := strings.ToLower(name)
+	if fenceName.MatchString(lower) || len(e.Aliases) == 0 {
+		return strings.ReplaceAll(lower, " ", "-")
+	}
+	return e.Aliases[0]
+}
+
+func writeList(buf *bytes.Buffer, field string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	fmt.Fprintf(buf, "\t\t%s: []string{%s},\n", field, strings.Join(quoted, ", "))
+}
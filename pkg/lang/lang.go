@@ -0,0 +1,162 @@
+// Package lang is a database of programming, markup, data and prose
+// languages modelled on GitHub Linguist.
+//
+// The table is generated from languages.yml and compiled into the package, so
+// lookups need no files at run time. Names, aliases and interpreters are
+// matched case-insensitively; file names are matched exactly.
+package lang
+
+//go:generate go run gen.go
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Type classifies a language the way Linguist does.
+type Type string
+
+// Language types.
+const (
+	Programming Type = "programming"
+	Markup      Type = "markup"
+	Data        Type = "data"
+	Prose       Type = "prose"
+)
+
+var types = []Type{Programming, Markup, Data, Prose}
+
+// ParseType returns the language type with the given name.
+func ParseType(name string) (Type, error) {
+	for _, t := range types {
+		if strings.EqualFold(name, string(t)) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown language type %q (available: %s)", name, strings.Join(TypeNames(), ", "))
+}
+
+// TypeNames returns the names of the language types.
+func TypeNames() []string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return names
+}
+
+// Language describes a single language.
+type Language struct {
+	// Name is the display name, such as "C++" or "TSX".
+	Name string
+	// Type is the kind of language.
+	Type Type
+	// Aliases are other names for the language, such as "cpp".
+	Aliases []string
+	// Extensions are the file extensions of the language, including the
+	// leading dot.
+	Extensions []string
+	// Filenames are exact file names of the language, such as "Makefile".
+	Filenames []string
+	// Interpreters are the programs named on the "#!" line of scripts in
+	// the language, without a version suffix.
+	Interpreters []string
+	// Fence is the identifier used to tag Markdown code blocks.
+	Fence string
+}
+
+var (
+	byName        = map[string]*Language{}
+	byExtension   = map[string][]*Language{}
+	byFilename    = map[string]*Language{}
+	byInterpreter = map[string]*Language{}
+)
+
+func init() {
+	for i := range languages {
+		l := &languages[i]
+		byName[strings.ToLower(l.Name)] = l
+		for _, alias := range l.Aliases {
+			byName[strings.ToLower(alias)] = l
+		}
+		for _, ext := range l.Extensions {
+			byExtension[ext] = append(byExtension[ext], l)
+		}
+		for _, name := range l.Filenames {
+			byFilename[name] = l
+		}
+		for _, interpreter := range l.Interpreters {
+			byInterpreter[interpreter] = l
+		}
+	}
+
+	// Order the languages sharing an extension by how early they list it,
+	// then by name, so the first is the extension's primary language.
+	for ext, candidates := range byExtension {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			pi, pj := position(candidates[i].Extensions, ext), position(candidates[j].Extensions, ext)
+			if pi != pj {
+				return pi < pj
+			}
+			return candidates[i].Name < candidates[j].Name
+		})
+	}
+}
+
+func position(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return len(values)
+}
+
+// All returns every language, sorted by name.
+func All() []Language {
+	all := make([]Language, len(languages))
+	copy(all, languages)
+	return all
+}
+
+// Lookup returns the language with the given name or alias.
+func Lookup(name string) (Language, bool) {
+	if l, ok := byName[strings.ToLower(name)]; ok {
+		return *l, true
+	}
+	return Language{}, false
+}
+
+// ByExtension returns the languages using the file extension ext, such as
+// ".h", with the most likely one first. It returns nil for unknown
+// extensions.
+func ByExtension(ext string) []Language {
+	candidates := byExtension[strings.ToLower(ext)]
+	if len(candidates) == 0 {
+		return nil
+	}
+	result := make([]Language, len(candidates))
+	for i, l := range candidates {
+		result[i] = *l
+	}
+	return result
+}
+
+// ByFilename returns the language of files with the exact name, such as
+// "Dockerfile" or "go.mod".
+func ByFilename(name string) (Language, bool) {
+	if l, ok := byFilename[name]; ok {
+		return *l, true
+	}
+	return Language{}, false
+}
+
+// ByInterpreter returns the language of scripts run by interpreter, such as
+// "python" or "bash".
+func ByInterpreter(interpreter string) (Language, bool) {
+	if l, ok := byInterpreter[strings.ToLower(interpreter)]; ok {
+		return *l, true
+	}
+	return Language{}, false
+}
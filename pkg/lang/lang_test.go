@@ -0,0 +1,131 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		want      string
+		wantType  Type
+		wantFence string
+	}{
+		{name: "Go", want: "Go", wantType: Programming, wantFence: "go"},
+		{name: "golang", want: "Go", wantType: Programming, wantFence: "go"},
+		{name: "TSX", want: "TSX", wantType: Programming, wantFence: "tsx"},
+		{name: "c#", want: "C#", wantType: Programming, wantFence: "csharp"},
+		{name: "CPP", want: "C++", wantType: Programming, wantFence: "cpp"},
+		{name: "common lisp", want: "Common Lisp", wantType: Programming, wantFence: "lisp"},
+		{name: "yml", want: "YAML", wantType: Data, wantFence: "yaml"},
+		{name: "md", want: "Markdown", wantType: Prose, wantFence: "markdown"},
+		{name: "bash", want: "Shell", wantType: Programming, wantFence: "sh"},
+		{name: "Pip Requirements", want: "Pip Requirements", wantType: Data, wantFence: "pip-requirements"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, ok := Lookup(tt.name)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, l.Name)
+			assert.Equal(t, tt.wantType, l.Type)
+			assert.Equal(t, tt.wantFence, l.Fence)
+		})
+	}
+
+	_, ok := Lookup("JavaScript (React)")
+	assert.False(t, ok)
+}
+
+func TestByExtension(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ext  string
+		want []string
+	}{
+		{ext: ".go", want: []string{"Go"}},
+		{ext: ".GO", want: []string{"Go"}},
+		{ext: ".tsx", want: []string{"TSX"}},
+		{ext: ".jsx", want: []string{"JavaScript"}},
+		{ext: ".h", want: []string{"C", "Objective-C", "C++"}},
+		{ext: ".m", want: []string{"Objective-C", "MATLAB"}},
+		{ext: ".v", want: []string{"V", "Verilog", "Coq"}},
+		{ext: ".pl", want: []string{"Perl", "Prolog"}},
+		{ext: ".xyz", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, l := range ByExtension(tt.ext) {
+				got = append(got, l.Name)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestByFilenameAndInterpreter(t *testing.T) {
+	t.Parallel()
+
+	l, ok := ByFilename("Dockerfile")
+	require.True(t, ok)
+	assert.Equal(t, "Dockerfile", l.Name)
+
+	l, ok = ByFilename("go.mod")
+	require.True(t, ok)
+	assert.Equal(t, "Go Module", l.Name)
+
+	_, ok = ByFilename("dockerfile")
+	assert.False(t, ok)
+
+	l, ok = ByInterpreter("Rscript")
+	require.True(t, ok)
+	assert.Equal(t, "R", l.Name)
+
+	_, ok = ByInterpreter("python3")
+	assert.False(t, ok, "interpreters are stored without version suffixes")
+}
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+
+	all := All()
+	require.NotEmpty(t, all)
+	seen := map[string]bool{}
+	for i, l := range all {
+		assert.NotEmpty(t, l.Fence, l.Name)
+		assert.Contains(t, types, l.Type, l.Name)
+		assert.False(t, seen[l.Name], "duplicate language %s", l.Name)
+		seen[l.Name] = true
+		if i > 0 {
+			assert.Less(t, strings.ToLower(all[i-1].Name), strings.ToLower(l.Name))
+		}
+	}
+
+	all[0].Name = "changed"
+	assert.NotEqual(t, "changed", All()[0].Name)
+}
+
+func TestParseType(t *testing.T) {
+	t.Parallel()
+
+	typ, err := ParseType("Programming")
+	require.NoError(t, err)
+	assert.Equal(t, Programming, typ)
+
+	_, err = ParseType("code")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "programming, markup, data, prose")
+}
@@ -0,0 +1,731 @@
+// Code generated by gen.go from languages.yml; DO NOT EDIT.
+
+package lang
+
+var languages = []Language{
+	{
+		Name:       "AsciiDoc",
+		Type:       Prose,
+		Extensions: []string{".adoc", ".asciidoc", ".asc"},
+		Fence:      "asciidoc",
+	},
+	{
+		Name:       "Assembly",
+		Type:       Programming,
+		Aliases:    []string{"asm", "nasm"},
+		Extensions: []string{".asm", ".s", ".nasm"},
+		Fence:      "asm",
+	},
+	{
+		Name:         "Awk",
+		Type:         Programming,
+		Extensions:   []string{".awk"},
+		Interpreters: []string{"awk", "gawk", "mawk", "nawk"},
+		Fence:        "awk",
+	},
+	{
+		Name:       "Batchfile",
+		Type:       Programming,
+		Aliases:    []string{"bat", "batch", "dosbatch", "winbatch"},
+		Extensions: []string{".bat", ".cmd"},
+		Fence:      "bat",
+	},
+	{
+		Name:       "BibTeX",
+		Type:       Markup,
+		Extensions: []string{".bib", ".bibtex"},
+		Fence:      "bibtex",
+	},
+	{
+		Name:         "C",
+		Type:         Programming,
+		Extensions:   []string{".c", ".h"},
+		Interpreters: []string{"tcc"},
+		Fence:        "c",
+	},
+	{
+		Name:       "C#",
+		Type:       Programming,
+		Aliases:    []string{"csharp", "cake", "cakescript"},
+		Extensions: []string{".cs", ".csx"},
+		Fence:      "csharp",
+	},
+	{
+		Name:       "C++",
+		Type:       Programming,
+		Aliases:    []string{"cpp"},
+		Extensions: []string{".cpp", ".cc", ".cxx", ".c++", ".hpp", ".hh", ".hxx", ".h++", ".h", ".ipp", ".tpp"},
+		Fence:      "cpp",
+	},
+	{
+		Name:       "Clojure",
+		Type:       Programming,
+		Extensions: []string{".clj", ".cljc", ".cljs", ".cljx", ".edn"},
+		Fence:      "clojure",
+	},
+	{
+		Name:       "CMake",
+		Type:       Programming,
+		Extensions: []string{".cmake"},
+		Filenames:  []string{"CMakeLists.txt"},
+		Fence:      "cmake",
+	},
+	{
+		Name:         "Common Lisp",
+		Type:         Programming,
+		Aliases:      []string{"lisp"},
+		Extensions:   []string{".lisp", ".cl", ".lsp"},
+		Interpreters: []string{"lisp", "sbcl", "ccl", "clisp", "ecl"},
+		Fence:        "lisp",
+	},
+	{
+		Name:       "Coq",
+		Type:       Programming,
+		Aliases:    []string{"rocq"},
+		Extensions: []string{".coq", ".v"},
+		Fence:      "coq",
+	},
+	{
+		Name:       "CSS",
+		Type:       Markup,
+		Extensions: []string{".css"},
+		Fence:      "css",
+	},
+	{
+		Name:       "CSV",
+		Type:       Data,
+		Extensions: []string{".csv"},
+		Fence:      "csv",
+	},
+	{
+		Name:         "Dart",
+		Type:         Programming,
+		Extensions:   []string{".dart"},
+		Interpreters: []string{"dart"},
+		Fence:        "dart",
+	},
+	{
+		Name:       "Diff",
+		Type:       Data,
+		Aliases:    []string{"udiff"},
+		Extensions: []string{".diff", ".patch"},
+		Fence:      "diff",
+	},
+	{
+		Name:       "Dockerfile",
+		Type:       Programming,
+		Aliases:    []string{"containerfile"},
+		Extensions: []string{".dockerfile", ".containerfile"},
+		Filenames:  []string{"Dockerfile", "Containerfile"},
+		Fence:      "dockerfile",
+	},
+	{
+		Name:       "Dotenv",
+		Type:       Data,
+		Extensions: []string{".env"},
+		Filenames:  []string{".env", ".env.example", ".env.local", ".env.development", ".env.production", ".env.test"},
+		Fence:      "dotenv",
+	},
+	{
+		Name:      "EditorConfig",
+		Type:      Data,
+		Aliases:   []string{"editor-config"},
+		Filenames: []string{".editorconfig"},
+		Fence:     "editorconfig",
+	},
+	{
+		Name:       "EJS",
+		Type:       Markup,
+		Extensions: []string{".ejs"},
+		Fence:      "ejs",
+	},
+	{
+		Name:         "Elixir",
+		Type:         Programming,
+		Extensions:   []string{".ex", ".exs"},
+		Interpreters: []string{"elixir"},
+		Fence:        "elixir",
+	},
+	{
+		Name:       "Elm",
+		Type:       Programming,
+		Extensions: []string{".elm"},
+		Fence:      "elm",
+	},
+	{
+		Name:       "Emacs Lisp",
+		Type:       Programming,
+		Aliases:    []string{"elisp", "emacs"},
+		Extensions: []string{".el"},
+		Filenames:  []string{".emacs", ".emacs.desktop"},
+		Fence:      "elisp",
+	},
+	{
+		Name:         "Erlang",
+		Type:         Programming,
+		Extensions:   []string{".erl", ".hrl", ".escript"},
+		Filenames:    []string{"rebar.config"},
+		Interpreters: []string{"escript"},
+		Fence:        "erlang",
+	},
+	{
+		Name:       "F#",
+		Type:       Programming,
+		Aliases:    []string{"fsharp"},
+		Extensions: []string{".fs", ".fsi", ".fsx"},
+		Fence:      "fsharp",
+	},
+	{
+		Name:       "Fortran",
+		Type:       Programming,
+		Extensions: []string{".f90", ".f95", ".f03", ".f08", ".f", ".for", ".f77"},
+		Fence:      "fortran",
+	},
+	{
+		Name:      "Git Attributes",
+		Type:      Data,
+		Aliases:   []string{"gitattributes"},
+		Filenames: []string{".gitattributes"},
+		Fence:     "gitattributes",
+	},
+	{
+		Name:       "Git Config",
+		Type:       Data,
+		Aliases:    []string{"gitconfig", "gitmodules"},
+		Extensions: []string{".gitconfig"},
+		Filenames:  []string{".gitconfig", ".gitmodules"},
+		Fence:      "gitconfig",
+	},
+	{
+		Name:       "Gleam",
+		Type:       Programming,
+		Extensions: []string{".gleam"},
+		Fence:      "gleam",
+	},
+	{
+		Name:       "Go",
+		Type:       Programming,
+		Aliases:    []string{"golang"},
+		Extensions: []string{".go"},
+		Fence:      "go",
+	},
+	{
+		Name:      "Go Checksums",
+		Type:      Data,
+		Aliases:   []string{"go.sum", "go sum", "go.work.sum"},
+		Filenames: []string{"go.sum", "go.work.sum"},
+		Fence:     "text",
+	},
+	{
+		Name:      "Go Module",
+		Type:      Data,
+		Aliases:   []string{"go.mod", "go mod"},
+		Filenames: []string{"go.mod"},
+		Fence:     "go.mod",
+	},
+	{
+		Name:      "Go Workspace",
+		Type:      Data,
+		Aliases:   []string{"go.work", "go work"},
+		Filenames: []string{"go.work"},
+		Fence:     "go.work",
+	},
+	{
+		Name:       "GraphQL",
+		Type:       Data,
+		Extensions: []string{".graphql", ".gql", ".graphqls"},
+		Fence:      "graphql",
+	},
+	{
+		Name:         "Groovy",
+		Type:         Programming,
+		Extensions:   []string{".groovy", ".gradle", ".gvy", ".jenkinsfile"},
+		Filenames:    []string{"Jenkinsfile"},
+		Interpreters: []string{"groovy"},
+		Fence:        "groovy",
+	},
+	{
+		Name:       "Handlebars",
+		Type:       Markup,
+		Aliases:    []string{"hbs", "htmlbars"},
+		Extensions: []string{".handlebars", ".hbs"},
+		Fence:      "handlebars",
+	},
+	{
+		Name:         "Haskell",
+		Type:         Programming,
+		Extensions:   []string{".hs", ".hs-boot", ".hsc"},
+		Interpreters: []string{"runghc", "runhaskell", "runhugs"},
+		Fence:        "haskell",
+	},
+	{
+		Name:       "HCL",
+		Type:       Programming,
+		Aliases:    []string{"terraform", "hashicorp configuration language"},
+		Extensions: []string{".hcl", ".tf", ".tfvars"},
+		Fence:      "hcl",
+	},
+	{
+		Name:       "HTML",
+		Type:       Markup,
+		Aliases:    []string{"xhtml"},
+		Extensions: []string{".html", ".htm", ".xht", ".xhtml"},
+		Fence:      "html",
+	},
+	{
+		Name:       "Ignore List",
+		Type:       Data,
+		Aliases:    []string{"ignore", "gitignore", "git-ignore"},
+		Extensions: []string{".gitignore"},
+		Filenames:  []string{".gitignore", ".dockerignore", ".npmignore", ".eslintignore", ".prettierignore", ".helmignore"},
+		Fence:      "gitignore",
+	},
+	{
+		Name:       "INI",
+		Type:       Data,
+		Aliases:    []string{"dosini"},
+		Extensions: []string{".ini", ".cfg", ".cnf", ".prefs", ".properties"},
+		Fence:      "ini",
+	},
+	{
+		Name:       "J",
+		Type:       Programming,
+		Extensions: []string{".ijs", ".j"},
+		Fence:      "j",
+	},
+	{
+		Name:       "Java",
+		Type:       Programming,
+		Extensions: []string{".java", ".jav", ".jsh"},
+		Fence:      "java",
+	},
+	{
+		Name:         "JavaScript",
+		Type:         Programming,
+		Aliases:      []string{"js", "node"},
+		Extensions:   []string{".js", ".cjs", ".mjs", ".jsx"},
+		Interpreters: []string{"node", "nodejs", "bun", "qjs"},
+		Fence:        "javascript",
+	},
+	{
+		Name:       "JSON",
+		Type:       Data,
+		Aliases:    []string{"geojson", "jsonl", "topojson"},
+		Extensions: []string{".json", ".jsonl", ".geojson", ".webmanifest"},
+		Filenames:  []string{".prettierrc", "composer.lock", "flake.lock"},
+		Fence:      "json",
+	},
+	{
+		Name:       "JSON with Comments",
+		Type:       Data,
+		Aliases:    []string{"jsonc"},
+		Extensions: []string{".jsonc", ".code-workspace"},
+		Filenames:  []string{"tsconfig.json", "jsconfig.json", "devcontainer.json", ".devcontainer.json"},
+		Fence:      "jsonc",
+	},
+	{
+		Name:         "Julia",
+		Type:         Programming,
+		Extensions:   []string{".jl"},
+		Interpreters: []string{"julia"},
+		Fence:        "julia",
+	},
+	{
+		Name:       "Kotlin",
+		Type:       Programming,
+		Extensions: []string{".kt", ".ktm", ".kts"},
+		Fence:      "kotlin",
+	},
+	{
+		Name:       "Less",
+		Type:       Markup,
+		Aliases:    []string{"less-css"},
+		Extensions: []string{".less"},
+		Fence:      "less",
+	},
+	{
+		Name:         "Lua",
+		Type:         Programming,
+		Extensions:   []string{".lua"},
+		Interpreters: []string{"lua", "luajit"},
+		Fence:        "lua",
+	},
+	{
+		Name:         "Makefile",
+		Type:         Programming,
+		Aliases:      []string{"bsdmake", "make", "mf"},
+		Extensions:   []string{".mak", ".make", ".mk", ".makefile"},
+		Filenames:    []string{"Makefile", "makefile", "GNUmakefile", "BSDmakefile", "Kbuild"},
+		Interpreters: []string{"make"},
+		Fence:        "makefile",
+	},
+	{
+		Name:       "Markdown",
+		Type:       Prose,
+		Aliases:    []string{"md", "pandoc"},
+		Extensions: []string{".md", ".markdown", ".mdown", ".mkd", ".mkdn", ".livemd"},
+		Fence:      "markdown",
+	},
+	{
+		Name:       "MATLAB",
+		Type:       Programming,
+		Aliases:    []string{"octave"},
+		Extensions: []string{".matlab", ".m"},
+		Fence:      "matlab",
+	},
+	{
+		Name:       "Mojo",
+		Type:       Programming,
+		Extensions: []string{".mojo"},
+		Fence:      "mojo",
+	},
+	{
+		Name:       "Nim",
+		Type:       Programming,
+		Extensions: []string{".nim", ".nimble", ".nims"},
+		Fence:      "nim",
+	},
+	{
+		Name:       "Nix",
+		Type:       Programming,
+		Aliases:    []string{"nixos"},
+		Extensions: []string{".nix"},
+		Fence:      "nix",
+	},
+	{
+		Name:       "Objective-C",
+		Type:       Programming,
+		Aliases:    []string{"obj-c", "objc", "objectivec"},
+		Extensions: []string{".m", ".h"},
+		Fence:      "objectivec",
+	},
+	{
+		Name:       "Objective-C++",
+		Type:       Programming,
+		Aliases:    []string{"obj-c++", "objc++", "objectivec++"},
+		Extensions: []string{".mm"},
+		Fence:      "objectivec++",
+	},
+	{
+		Name:         "OCaml",
+		Type:         Programming,
+		Extensions:   []string{".ml", ".mli", ".mll", ".mly"},
+		Interpreters: []string{"ocaml", "ocamlrun", "ocamlscript"},
+		Fence:        "ocaml",
+	},
+	{
+		Name:         "Perl",
+		Type:         Programming,
+		Aliases:      []string{"cperl"},
+		Extensions:   []string{".pl", ".pm", ".t", ".cgi", ".psgi"},
+		Interpreters: []string{"perl", "cperl"},
+		Fence:        "perl",
+	},
+	{
+		Name:         "PHP",
+		Type:         Programming,
+		Aliases:      []string{"inc"},
+		Extensions:   []string{".php", ".phtml", ".php3", ".php4", ".php5", ".phps"},
+		Interpreters: []string{"php"},
+		Fence:        "php",
+	},
+	{
+		Name:      "Pip Requirements",
+		Type:      Data,
+		Filenames: []string{"requirements.txt", "requirements-dev.txt", "requirements-test.txt", "constraints.txt"},
+		Fence:     "pip-requirements",
+	},
+	{
+		Name:         "PowerShell",
+		Type:         Programming,
+		Aliases:      []string{"posh", "pwsh"},
+		Extensions:   []string{".ps1", ".psd1", ".psm1"},
+		Interpreters: []string{"pwsh", "powershell"},
+		Fence:        "powershell",
+	},
+	{
+		Name:      "Procfile",
+		Type:      Programming,
+		Filenames: []string{"Procfile"},
+		Fence:     "procfile",
+	},
+	{
+		Name:         "Prolog",
+		Type:         Programming,
+		Extensions:   []string{".pl", ".pro", ".prolog", ".yap"},
+		Interpreters: []string{"swipl", "yap"},
+		Fence:        "prolog",
+	},
+	{
+		Name:       "Protocol Buffer",
+		Type:       Data,
+		Aliases:    []string{"proto", "protobuf", "protocol buffers"},
+		Extensions: []string{".proto"},
+		Fence:      "protobuf",
+	},
+	{
+		Name:       "Pug",
+		Type:       Markup,
+		Aliases:    []string{"jade"},
+		Extensions: []string{".pug", ".jade"},
+		Fence:      "pug",
+	},
+	{
+		Name:         "Python",
+		Type:         Programming,
+		Aliases:      []string{"python3", "py"},
+		Extensions:   []string{".py", ".pyi", ".pyw", ".gyp"},
+		Filenames:    []string{"SConstruct", "SConscript", ".pythonrc"},
+		Interpreters: []string{"python", "py", "pypy"},
+		Fence:        "python",
+	},
+	{
+		Name:         "R",
+		Type:         Programming,
+		Aliases:      []string{"rscript", "splus"},
+		Extensions:   []string{".r", ".rd", ".rsx"},
+		Interpreters: []string{"rscript"},
+		Fence:        "r",
+	},
+	{
+		Name:         "Racket",
+		Type:         Programming,
+		Extensions:   []string{".rkt", ".rktd", ".rktl", ".scrbl"},
+		Interpreters: []string{"racket"},
+		Fence:        "racket",
+	},
+	{
+		Name:       "reStructuredText",
+		Type:       Prose,
+		Aliases:    []string{"rst"},
+		Extensions: []string{".rst", ".rest"},
+		Fence:      "rst",
+	},
+	{
+		Name:       "Rich Text Format",
+		Type:       Markup,
+		Extensions: []string{".rtf"},
+		Fence:      "rtf",
+	},
+	{
+		Name:         "Ruby",
+		Type:         Programming,
+		Aliases:      []string{"jruby", "macruby", "rake", "rb", "rbx"},
+		Extensions:   []string{".rb", ".rake", ".gemspec", ".ru"},
+		Filenames:    []string{"Gemfile", "Rakefile", "Vagrantfile", "Podfile", "Brewfile", "Guardfile", "Fastfile", ".irbrc"},
+		Interpreters: []string{"ruby", "macruby", "rake", "jruby", "rbx"},
+		Fence:        "ruby",
+	},
+	{
+		Name:       "Rust",
+		Type:       Programming,
+		Aliases:    []string{"rs"},
+		Extensions: []string{".rs"},
+		Fence:      "rust",
+	},
+	{
+		Name:       "SAS",
+		Type:       Programming,
+		Extensions: []string{".sas"},
+		Fence:      "sas",
+	},
+	{
+		Name:       "Sass",
+		Type:       Markup,
+		Extensions: []string{".sass"},
+		Fence:      "sass",
+	},
+	{
+		Name:         "Scala",
+		Type:         Programming,
+		Extensions:   []string{".scala", ".sbt", ".sc"},
+		Interpreters: []string{"scala"},
+		Fence:        "scala",
+	},
+	{
+		Name:         "Scheme",
+		Type:         Programming,
+		Extensions:   []string{".scm", ".ss", ".sld", ".sls", ".sps"},
+		Interpreters: []string{"scheme", "guile", "chicken", "csi", "gosh"},
+		Fence:        "scheme",
+	},
+	{
+		Name:       "SCSS",
+		Type:       Markup,
+		Extensions: []string{".scss"},
+		Fence:      "scss",
+	},
+	{
+		Name:         "Shell",
+		Type:         Programming,
+		Aliases:      []string{"sh", "shell-script", "bash", "zsh", "fish", "envrc"},
+		Extensions:   []string{".sh", ".bash", ".zsh", ".ksh", ".fish", ".command"},
+		Filenames:    []string{".bashrc", ".bash_profile", ".bash_aliases", ".bash_logout", ".profile", ".zshrc", ".zshenv", ".zprofile", ".zlogin", ".zlogout", ".login", ".envrc"},
+		Interpreters: []string{"ash", "bash", "dash", "ksh", "mksh", "pdksh", "sh", "zsh", "fish"},
+		Fence:        "sh",
+	},
+	{
+		Name:       "SQL",
+		Type:       Data,
+		Extensions: []string{".sql"},
+		Fence:      "sql",
+	},
+	{
+		Name:       "Standard ML",
+		Type:       Programming,
+		Aliases:    []string{"sml"},
+		Extensions: []string{".sml", ".sig", ".fun"},
+		Fence:      "sml",
+	},
+	{
+		Name:       "Starlark",
+		Type:       Programming,
+		Aliases:    []string{"bazel", "bzl"},
+		Extensions: []string{".bzl", ".star"},
+		Filenames:  []string{"BUILD", "BUILD.bazel", "WORKSPACE", "WORKSPACE.bazel", "MODULE.bazel", "Tiltfile"},
+		Fence:      "starlark",
+	},
+	{
+		Name:       "Svelte",
+		Type:       Markup,
+		Extensions: []string{".svelte"},
+		Fence:      "svelte",
+	},
+	{
+		Name:       "SVG",
+		Type:       Data,
+		Extensions: []string{".svg"},
+		Fence:      "svg",
+	},
+	{
+		Name:         "Swift",
+		Type:         Programming,
+		Extensions:   []string{".swift"},
+		Interpreters: []string{"swift"},
+		Fence:        "swift",
+	},
+	{
+		Name:         "Tcl",
+		Type:         Programming,
+		Extensions:   []string{".tcl", ".tm"},
+		Interpreters: []string{"tclsh", "wish"},
+		Fence:        "tcl",
+	},
+	{
+		Name:       "TeX",
+		Type:       Markup,
+		Aliases:    []string{"latex"},
+		Extensions: []string{".tex", ".ltx", ".sty", ".cls"},
+		Fence:      "tex",
+	},
+	{
+		Name:       "Text",
+		Type:       Prose,
+		Aliases:    []string{"fundamental", "plain text"},
+		Extensions: []string{".txt", ".text"},
+		Filenames:  []string{"COPYING", "LICENSE", "INSTALL", "NOTICE"},
+		Fence:      "text",
+	},
+	{
+		Name:       "TOML",
+		Type:       Data,
+		Extensions: []string{".toml"},
+		Filenames:  []string{"Cargo.lock", "Pipfile", "Gopkg.lock", "poetry.lock", "uv.lock"},
+		Fence:      "toml",
+	},
+	{
+		Name:       "TSV",
+		Type:       Data,
+		Aliases:    []string{"tab-seperated values"},
+		Extensions: []string{".tsv"},
+		Fence:      "tsv",
+	},
+	{
+		Name:       "TSX",
+		Type:       Programming,
+		Extensions: []string{".tsx"},
+		Fence:      "tsx",
+	},
+	{
+		Name:         "TypeScript",
+		Type:         Programming,
+		Aliases:      []string{"ts"},
+		Extensions:   []string{".ts", ".cts", ".mts"},
+		Interpreters: []string{"deno", "ts-node", "tsx"},
+		Fence:        "typescript",
+	},
+	{
+		Name:       "V",
+		Type:       Programming,
+		Aliases:    []string{"vlang"},
+		Extensions: []string{".v", ".vsh", ".vv"},
+		Fence:      "v",
+	},
+	{
+		Name:       "Verilog",
+		Type:       Programming,
+		Extensions: []string{".v", ".veo"},
+		Fence:      "verilog",
+	},
+	{
+		Name:       "VHDL",
+		Type:       Programming,
+		Extensions: []string{".vhdl", ".vhd"},
+		Fence:      "vhdl",
+	},
+	{
+		Name:       "Vim Script",
+		Type:       Programming,
+		Aliases:    []string{"vim", "viml", "nvim", "vimscript"},
+		Extensions: []string{".vim", ".vmb"},
+		Filenames:  []string{".vimrc", "_vimrc", ".exrc", ".gvimrc", "vimrc", "gvimrc"},
+		Fence:      "vim",
+	},
+	{
+		Name:       "Visual Basic .NET",
+		Type:       Programming,
+		Aliases:    []string{"vb.net", "vbnet"},
+		Extensions: []string{".vb", ".vbhtml"},
+		Fence:      "vbnet",
+	},
+	{
+		Name:       "Vue",
+		Type:       Markup,
+		Extensions: []string{".vue"},
+		Fence:      "vue",
+	},
+	{
+		Name:       "XML",
+		Type:       Data,
+		Aliases:    []string{"rss", "xsd", "wsdl"},
+		Extensions: []string{".xml", ".xsd", ".rss", ".csproj", ".props", ".targets"},
+		Fence:      "xml",
+	},
+	{
+		Name:       "XML Property List",
+		Type:       Data,
+		Extensions: []string{".plist", ".plst"},
+		Fence:      "xml",
+	},
+	{
+		Name:       "XSLT",
+		Type:       Programming,
+		Aliases:    []string{"xsl"},
+		Extensions: []string{".xslt", ".xsl"},
+		Fence:      "xslt",
+	},
+	{
+		Name:       "YAML",
+		Type:       Data,
+		Aliases:    []string{"yml"},
+		Extensions: []string{".yml", ".yaml"},
+		Filenames:  []string{".clang-format", ".clang-tidy", ".gemrc"},
+		Fence:      "yaml",
+	},
+	{
+		Name:       "Zig",
+		Type:       Programming,
+		Extensions: []string{".zig"},
+		Fence:      "zig",
+	},
+}
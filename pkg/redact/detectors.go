@@ -0,0 +1,195 @@
+package redact
+
+import (
+	"math"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultDetectors returns the built-in detectors: PEM private keys, AWS,
+// GCP, GitHub and Slack credentials, the values of .env files and
+// high-entropy strings assigned to names.
+func DefaultDetectors() []Detector {
+	return []Detector{
+		PatternDetector{Kind: "private-key", Pattern: pemPrivateKey},
+		PatternDetector{Kind: "aws-access-key", Pattern: awsAccessKey},
+		PatternDetector{Kind: "aws-secret-key", Pattern: awsSecretKey, Group: 1},
+		PatternDetector{Kind: "gcp-api-key", Pattern: gcpAPIKey},
+		PatternDetector{Kind: "github-token", Pattern: githubToken},
+		PatternDetector{Kind: "slack-token", Pattern: slackToken},
+		PatternDetector{Kind: "slack-webhook", Pattern: slackWebhook},
+		EnvDetector{},
+		EntropyDetector{},
+	}
+}
+
+var (
+	pemPrivateKey = regexp.MustCompile(`-----BEGIN[A-Z ]* PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END[A-Z ]* PRIVATE KEY( BLOCK)?-----`)
+	awsAccessKey  = regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`)
+	awsSecretKey  = regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})\b`)
+	gcpAPIKey     = regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)
+	githubToken   = regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)
+	slackToken    = regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)
+	slackWebhook  = regexp.MustCompile(`https://hooks\.slack\.com/(?:services|workflows)/[A-Za-z0-9+/_-]+`)
+)
+
+// PatternDetector finds the matches of a regular expression, or of one of
+// its groups.
+type PatternDetector struct {
+	Kind    string
+	Pattern *regexp.Regexp
+	// Group is the index of the group that holds the secret; zero means the
+	// whole match.
+	Group int
+}
+
+func (d PatternDetector) Detect(_, content string) []Match {
+	var matches []Match
+	for _, loc := range d.Pattern.FindAllStringSubmatchIndex(content, -1) {
+		start, end := loc[2*d.Group], loc[2*d.Group+1]
+		if start >= 0 {
+			matches = append(matches, Match{Start: start, End: end, Kind: d.Kind})
+		}
+	}
+	return matches
+}
+
+// envLine matches a "KEY=value" line of a .env file, with an optional
+// "export" and optional quotes around the value. Lines may start with the
+// "+" or "-" of a unified diff.
+var envLine = regexp.MustCompile(`(?m)^[+-]?[ \t]*(?:export[ \t]+)?[A-Za-z_][A-Za-z0-9_.]*[ \t]*=[ \t]*(?:"([^"\n]*)"|'([^'\n]*)'|([^ \t\r\n#][^\r\n#]*?))[ \t]*(?:#.*)?\r?$`)
+
+// EnvDetector finds the values of .env files, such as ".env",
+// ".env.production" or "prod.env", as kind "env-value". Variable names and
+// comments are kept, so the file still shows what must be configured.
+type EnvDetector struct{}
+
+func (EnvDetector) Detect(filePath, content string) []Match {
+	if !isEnvFile(filePath) {
+		return nil
+	}
+	var matches []Match
+	for _, loc := range envLine.FindAllStringSubmatchIndex(content, -1) {
+		for group := 1; group <= 3; group++ {
+			start, end := loc[2*group], loc[2*group+1]
+			if start >= 0 && end > start {
+				matches = append(matches, Match{Start: start, End: end, Kind: "env-value"})
+			}
+		}
+	}
+	return matches
+}
+
+// assignment matches a quoted or bare value of at least 16 characters
+// assigned to a name with "=" or ":", as in configuration files and source
+// code.
+var assignment = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_.-]*)["']?[ \t]*(?::=|=|:)[ \t]*["'` + "`" + `]?([A-Za-z0-9+/=_\-.~]{16,})`)
+
+// checksumName matches names whose values are checksums or versions rather
+// than secrets.
+var checksumName = regexp.MustCompile(`(?i)(sha[0-9]*|md5|hash|checksum|digest|integrity|commit|version|revision|uuid|id)$`)
+
+// EntropyDetector finds random-looking strings assigned to names, such as
+// generated passwords and API keys of services without a known pattern, as
+// kind "high-entropy". A string is random-looking when its Shannon entropy
+// is at least MinEntropy bits per character (4.0 by default) for letters and
+// digits, or 3.0 for hexadecimal strings. Values of names ending in "sha",
+// "hash", "id" or "version" and the like, and lock files such as go.sum, are
+// ignored since they hold checksums.
+type EntropyDetector struct {
+	MinEntropy float64
+}
+
+// lockFiles hold checksums that look like high-entropy secrets.
+var lockFiles = map[string]bool{
+	"go.sum":            true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"Cargo.lock":        true,
+	"Gemfile.lock":      true,
+	"poetry.lock":       true,
+	"composer.lock":     true,
+}
+
+func (d EntropyDetector) Detect(filePath, content string) []Match {
+	if lockFiles[path.Base(filepath.ToSlash(filePath))] {
+		return nil
+	}
+	minEntropy := d.MinEntropy
+	if minEntropy == 0 {
+		minEntropy = 4.0
+	}
+
+	var matches []Match
+	for _, loc := range assignment.FindAllStringSubmatchIndex(content, -1) {
+		name := content[loc[2]:loc[3]]
+		value := content[loc[4]:loc[5]]
+		if checksumName.MatchString(name) || !mixed(value) || !opaque(value) || sequential(value) {
+			continue
+		}
+		if loc[5] < len(content) && content[loc[5]] == '(' {
+			continue // a function call, such as utf8.DecodeRuneInString(s)
+		}
+		threshold := minEntropy
+		if isHex(value) {
+			threshold = minEntropy - 1
+		}
+		if entropy(value) >= threshold {
+			matches = append(matches, Match{Start: loc[4], End: loc[5], Kind: "high-entropy"})
+		}
+	}
+	return matches
+}
+
+// entropy returns the Shannon entropy of s in bits per byte.
+func entropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var h float64
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(len(s))
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// mixed reports whether s has both letters and digits, which sets keys and
+// tokens apart from words and identifiers.
+func mixed(s string) bool {
+	return strings.ContainsAny(s, "0123456789") &&
+		strings.ContainsAny(strings.ToLower(s), "abcdefghijklmnopqrstuvwxyz")
+}
+
+// opaque reports whether s could be a key rather than a path, URL or
+// qualified name, which have dots or start with a slash.
+func opaque(s string) bool {
+	return !strings.Contains(s, ".") && !strings.HasPrefix(s, "/")
+}
+
+// sequential reports whether most characters of s follow the previous one,
+// as in "0123456789abcdef", which test data and alphabets are made of.
+func sequential(s string) bool {
+	steps := 0
+	for i := 1; i < len(s); i++ {
+		if s[i] == s[i-1]+1 {
+			steps++
+		}
+	}
+	return steps*2 >= len(s)-1
+}
+
+func isHex(s string) bool {
+	for _, c := range strings.ToLower(s) {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
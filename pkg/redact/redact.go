@@ -0,0 +1,161 @@
+// Package redact finds secrets such as private keys, API tokens and the
+// values of .env files in text and replaces them with typed placeholders,
+// like "[REDACTED:github-token]", so that file contents can be shared with
+// third-party services without leaking credentials.
+package redact
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Match is a secret found in a piece of text.
+type Match struct {
+	// Start and End are the byte offsets of the secret in the text.
+	Start, End int
+	// Kind identifies what the secret is, such as "private-key".
+	Kind string
+}
+
+// Detector finds secrets of some kind in the contents of a file.
+type Detector interface {
+	Detect(filePath, content string) []Match
+}
+
+// Placeholder returns the text that replaces a secret of the given kind.
+func Placeholder(kind string) string {
+	return fmt.Sprintf("[REDACTED:%s]", kind)
+}
+
+// Redactor replaces the secrets its detectors find and keeps count of them
+// for Summary. It is safe for concurrent use.
+type Redactor struct {
+	detectors []Detector
+
+	mu    sync.Mutex
+	found map[string]map[string]int
+}
+
+// New returns a Redactor using detectors, or DefaultDetectors if none are
+// given.
+func New(detectors ...Detector) *Redactor {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	return &Redactor{detectors: detectors, found: make(map[string]map[string]int)}
+}
+
+// Redact returns content with every secret found in it replaced by its
+// placeholder, and the secrets found, in order. Overlapping matches are
+// merged into the first one.
+func (r *Redactor) Redact(filePath, content string) (string, []Match) {
+	redacted, matches := r.replace(filePath, content)
+	r.record(filePath, matches)
+	return redacted, matches
+}
+
+// replace is Redact without keeping count of the secrets.
+func (r *Redactor) replace(filePath, content string) (string, []Match) {
+	var matches []Match
+	for _, d := range r.detectors {
+		matches = append(matches, d.Detect(filePath, content)...)
+	}
+	if len(matches) == 0 {
+		return content, nil
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Start != matches[j].Start {
+			return matches[i].Start < matches[j].Start
+		}
+		return matches[i].End > matches[j].End
+	})
+
+	var sb strings.Builder
+	kept := matches[:0]
+	last := 0
+	for _, m := range matches {
+		if m.Start < last {
+			continue
+		}
+		sb.WriteString(content[last:m.Start])
+		sb.WriteString(Placeholder(m.Kind))
+		last = m.End
+		kept = append(kept, m)
+	}
+	sb.WriteString(content[last:])
+	return sb.String(), kept
+}
+
+// record counts matches, found in filePath, for Summary.
+func (r *Redactor) record(filePath string, matches []Match) {
+	if len(matches) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kinds := r.found[filePath]
+	if kinds == nil {
+		kinds = make(map[string]int)
+		r.found[filePath] = kinds
+	}
+	for _, m := range matches {
+		kinds[m.Kind]++
+	}
+}
+
+// FileSummary counts the secrets redacted from a file by kind.
+type FileSummary struct {
+	Path  string
+	Kinds map[string]int
+}
+
+// Total returns the number of secrets redacted from the file.
+func (s FileSummary) Total() int {
+	total := 0
+	for _, n := range s.Kinds {
+		total += n
+	}
+	return total
+}
+
+// String describes the secrets redacted from the file, such as
+// "2 env-value, 1 private-key".
+func (s FileSummary) String() string {
+	kinds := make([]string, 0, len(s.Kinds))
+	for kind := range s.Kinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", s.Kinds[kind], kind)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Summary returns the secrets redacted so far, by file in sorted path order.
+func (r *Redactor) Summary() []FileSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	summary := make([]FileSummary, 0, len(r.found))
+	for filePath, kinds := range r.found {
+		counts := make(map[string]int, len(kinds))
+		for kind, n := range kinds {
+			counts[kind] = n
+		}
+		summary = append(summary, FileSummary{Path: filePath, Kinds: counts})
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Path < summary[j].Path })
+	return summary
+}
+
+// isEnvFile reports whether filePath names a dotenv file, such as ".env",
+// ".env.production" or "prod.env".
+func isEnvFile(filePath string) bool {
+	name := path.Base(filepath.ToSlash(filePath))
+	return name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env")
+}
@@ -0,0 +1,142 @@
+package redact
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	// chunkSize is the size of the chunks of whole lines Scan and NewReader
+	// redact at a time.
+	chunkSize = 64 << 10
+	// maxKeySize bounds how far a chunk grows past chunkSize to keep a PEM
+	// private key in one piece.
+	maxKeySize = 1 << 20
+	// maxSecretSize bounds how far a line longer than chunkSize is cut
+	// before the end of a chunk so that no secret is split.
+	maxSecretSize = 4 << 10
+)
+
+// Scan reads src in chunks, like NewReader, counts the secrets found in it
+// for Summary, like Redact, and returns how many there are and the size of
+// the contents of src with them replaced.
+func (r *Redactor) Scan(filePath string, src io.Reader) (int, int64, error) {
+	chunks := newChunker(src)
+	var matches int
+	var size int64
+	for {
+		chunk, err := chunks.next()
+		redacted, found := r.replace(filePath, chunk)
+		r.record(filePath, found)
+		matches += len(found)
+		size += int64(len(redacted))
+		if err == io.EOF {
+			return matches, size, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+}
+
+// NewReader returns a reader of the contents of src with every secret found
+// in them replaced by its placeholder, like Redact, for files too large to
+// hold in memory. src is read and redacted in chunks of about 64 KiB of whole
+// lines, and a chunk is extended until a PEM private key starting in it ends,
+// so detectors only miss secrets of other kinds that span lines across
+// chunks. The secrets are not counted for Summary, so that a file can be
+// passed to Scan first to learn the size of its redacted contents.
+func (r *Redactor) NewReader(filePath string, src io.Reader) io.Reader {
+	return &reader{redactor: r, filePath: filePath, chunks: newChunker(src)}
+}
+
+// reader is the reader returned by NewReader.
+type reader struct {
+	redactor *Redactor
+	filePath string
+	chunks   *chunker
+	pending  string
+	err      error
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for r.pending == "" && r.err == nil {
+		var chunk string
+		chunk, r.err = r.chunks.next()
+		r.pending, _ = r.redactor.replace(r.filePath, chunk)
+	}
+	if r.pending == "" {
+		return 0, r.err
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// chunker splits a reader into chunks that can be redacted on their own.
+type chunker struct {
+	src *bufio.Reader
+	// carry is the end of a line cut by the previous chunk.
+	carry string
+}
+
+func newChunker(src io.Reader) *chunker {
+	return &chunker{src: bufio.NewReaderSize(src, chunkSize)}
+}
+
+// next returns the next chunk: whole lines of at least chunkSize bytes,
+// followed by the rest of a PEM private key starting in them, or the rest of
+// the input along with io.EOF. Lines longer than chunkSize are cut where no
+// secret can be split.
+func (c *chunker) next() (string, error) {
+	var sb strings.Builder
+	sb.WriteString(c.carry)
+	c.carry = ""
+	for {
+		line, err := c.src.ReadSlice('\n')
+		sb.Write(line)
+		if err != nil && err != bufio.ErrBufferFull {
+			return sb.String(), err
+		}
+		if sb.Len() < chunkSize || (sb.Len() < chunkSize+maxKeySize && openPrivateKey(sb.String())) {
+			continue
+		}
+		chunk := sb.String()
+		if err == bufio.ErrBufferFull {
+			chunk, c.carry = cutLine(chunk)
+		}
+		return chunk, nil
+	}
+}
+
+var (
+	pemBegin = regexp.MustCompile(`-----BEGIN[A-Z ]* PRIVATE KEY( BLOCK)?-----`)
+	pemEnd   = regexp.MustCompile(`-----END[A-Z ]* PRIVATE KEY( BLOCK)?-----`)
+)
+
+// openPrivateKey reports whether s ends inside a PEM private key.
+func openPrivateKey(s string) bool {
+	begins := pemBegin.FindAllStringIndex(s, -1)
+	if len(begins) == 0 {
+		return false
+	}
+	return !pemEnd.MatchString(s[begins[len(begins)-1][1]:])
+}
+
+// cutLine splits s, which ends inside a line, after its last byte within
+// maxSecretSize of the end that cannot be part of a secret.
+func cutLine(s string) (string, string) {
+	for i := len(s) - 1; i >= 0 && i >= len(s)-maxSecretSize; i-- {
+		if !isSecretByte(s[i]) {
+			return s[:i+1], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+// isSecretByte reports whether c can be part of a key, token or password.
+func isSecretByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("+/=_-.~", c) >= 0
+}
@@ -0,0 +1,110 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// BPE is a byte-pair encoder in the style of tiktoken.
+//
+// Text is first split into pieces by a pre-tokenizer, then each piece is
+// encoded by repeatedly merging the adjacent byte sequences with the lowest
+// rank until no mergeable pair remains.
+type BPE struct {
+	name  string
+	ranks map[string]int
+	split func(string) []string
+}
+
+// NewBPE returns a byte-pair encoder using ranks as its merge table and split
+// as its pre-tokenizer.
+func NewBPE(name string, ranks map[string]int, split func(string) []string) *BPE {
+	return &BPE{name: name, ranks: ranks, split: split}
+}
+
+func (b *BPE) Name() string { return b.name }
+
+func (b *BPE) Count(text string) int {
+	count := 0
+	for _, piece := range b.split(text) {
+		if _, ok := b.ranks[piece]; ok {
+			count++
+			continue
+		}
+		count += len(b.encode(piece))
+	}
+	return count
+}
+
+// Encode returns the token ranks for text.
+func (b *BPE) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range b.split(text) {
+		if rank, ok := b.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		for _, part := range b.encode(piece) {
+			tokens = append(tokens, b.ranks[part])
+		}
+	}
+	return tokens
+}
+
+// encode splits piece into the byte sequences produced by merging.
+func (b *BPE) encode(piece string) []string {
+	parts := make([]string, len(piece))
+	for i := 0; i < len(piece); i++ {
+		parts[i] = piece[i : i+1]
+	}
+	for len(parts) > 1 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, ok := b.ranks[parts[i]+parts[i+1]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
+
+// LoadRanks reads a tiktoken vocabulary: one base64-encoded token and its rank
+// per line, separated by a space.
+func LoadRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"<token> <rank>\"", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ranks, nil
+}
@@ -0,0 +1,139 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBPE_Encode(t *testing.T) {
+	t.Parallel()
+
+	ranks := map[string]int{
+		"a": 0, "b": 1, "c": 2, "d": 3,
+		"ab": 4, "cd": 5, "abcd": 6, "bc": 7,
+	}
+	bpe := NewBPE("test", ranks, func(s string) []string { return strings.Fields(s) })
+
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{text: "abcd", want: []int{6}},
+		// "ab" (4) merges before "bc" (7), then "cd" (5), then "abcd" (6).
+		{text: "abcdab", want: []int{6, 4}},
+		// "ab" (4) wins over "bc" (7) and leaves "c" unmergeable.
+		{text: "abca", want: []int{4, 2, 0}},
+		{text: "bca", want: []int{7, 0}},
+		{text: "dcba", want: []int{3, 2, 1, 0}},
+		{text: "ab cd", want: []int{4, 5}},
+		{text: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, bpe.Encode(tt.text))
+			assert.Equal(t, len(tt.want), bpe.Count(tt.text))
+		})
+	}
+}
+
+func TestLoadRanks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		input       string
+		want        map[string]int
+		expectedErr string
+	}{
+		{
+			name:  "valid",
+			input: "IQ== 0\nIg== 1\n\naGVsbG8= 2\n",
+			want:  map[string]int{"!": 0, `"`: 1, "hello": 2},
+		},
+		{
+			name:        "missing rank",
+			input:       "IQ==\n",
+			expectedErr: "line 1",
+		},
+		{
+			name:        "bad base64",
+			input:       "IQ== 0\n!!! 1\n",
+			expectedErr: "line 2",
+		},
+		{
+			name:        "bad rank",
+			input:       "IQ== zero\n",
+			expectedErr: "line 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ranks, err := LoadRanks(strings.NewReader(tt.input))
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ranks)
+		})
+	}
+}
+
+func TestSplitCL100K(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Hello world", want: []string{"Hello", " world"}},
+		{text: "  foo", want: []string{" ", " foo"}},
+		{text: "foo  ", want: []string{"foo", "  "}},
+		{text: "a\n\nb", want: []string{"a", "\n\n", "b"}},
+		{text: "1234567", want: []string{"123", "456", "7"}},
+		{text: "don't", want: []string{"don", "'t"}},
+		{text: "x := y()", want: []string{"x", " :=", " y", "()"}},
+		{text: "\tfunc", want: []string{"\tfunc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, splitCL100K(tt.text))
+		})
+	}
+}
+
+func TestSplitO200K(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "HelloWorld", want: []string{"Hello", "World"}},
+		{text: "HTTPServer", want: []string{"HTTPServer"}},
+		{text: "don't stop", want: []string{"don't", " stop"}},
+		{text: "a/b\n", want: []string{"a", "/b", "\n"}},
+		{text: "foo  bar", want: []string{"foo", " ", " bar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, splitO200K(tt.text))
+		})
+	}
+}
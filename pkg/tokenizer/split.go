@@ -0,0 +1,74 @@
+package tokenizer
+
+import (
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The pre-tokenizer patterns below are the cl100k_base and o200k_base patterns
+// with their trailing `\s+(?!\S)|\s+` alternatives removed. Go's regexp has no
+// lookahead, so newSplitter handles those whitespace runs by hand.
+//
+// Go's \s only covers ASCII whitespace, so `[\s\v\x{85}\p{Z}]` is used where
+// the upstream patterns rely on Unicode \s.
+const (
+	ws       = `\s\v\x{85}\p{Z}`
+	suffixes = `'s|'t|'re|'ve|'m|'ll|'d`
+
+	cl100kPattern = `(?i:` + suffixes + `)` +
+		`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + ws + `\p{L}\p{N}]+[\r\n]*` +
+		`|[` + ws + `]*[\r\n]+`
+
+	o200kPattern = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:` + suffixes + `)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:` + suffixes + `)?` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + ws + `\p{L}\p{N}]+[\r\n/]*` +
+		`|[` + ws + `]*[\r\n]+`
+)
+
+var (
+	splitCL100K = newSplitter(cl100kPattern)
+	splitO200K  = newSplitter(o200kPattern)
+)
+
+// newSplitter returns a pre-tokenizer that splits text with pattern, falling
+// back to the `\s+(?!\S)|\s+` rule when pattern does not match.
+func newSplitter(pattern string) func(string) []string {
+	re := regexp.MustCompile(`^(?:` + pattern + `)`)
+	return func(text string) []string {
+		var pieces []string
+		for i := 0; i < len(text); {
+			if loc := re.FindStringIndex(text[i:]); loc != nil && loc[1] > 0 {
+				pieces = append(pieces, text[i:i+loc[1]])
+				i += loc[1]
+				continue
+			}
+
+			j := i
+			for j < len(text) {
+				r, size := utf8.DecodeRuneInString(text[j:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				j += size
+			}
+			switch {
+			case j == i:
+				// Not whitespace either; emit the rune on its own.
+				_, size := utf8.DecodeRuneInString(text[i:])
+				j = i + size
+			case j < len(text):
+				// Leave the last whitespace rune to prefix the next piece.
+				if _, size := utf8.DecodeLastRuneInString(text[i:j]); j-size > i {
+					j -= size
+				}
+			}
+			pieces = append(pieces, text[i:j])
+			i = j
+		}
+		return pieces
+	}
+}
@@ -0,0 +1,110 @@
+// Package tokenizer estimates how many tokens a language model will see for a
+// piece of text.
+//
+// Two kinds of tokenizers are provided: a Heuristic that assumes roughly four
+// characters per token, and byte-pair encoders (BPE) compatible with the
+// cl100k_base and o200k_base encodings. The BPE vocabularies are embedded in
+// the package so counting works fully offline.
+package tokenizer
+
+import (
+	"compress/gzip"
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens in a piece of text.
+type Tokenizer interface {
+	Name() string
+	Count(text string) int
+}
+
+// Names of the built-in tokenizers accepted by Get.
+const (
+	HeuristicName = "heuristic"
+	CL100KBase    = "cl100k_base"
+	O200KBase     = "o200k_base"
+)
+
+// vocabs holds the tiktoken vocabulary of each BPE encoding, gzipped, as
+// published by OpenAI at https://openaipublic.blob.core.windows.net/encodings/.
+//
+//go:embed vocab/*.tiktoken.gz
+var vocabs embed.FS
+
+// Heuristic estimates one token for every four characters.
+type Heuristic struct{}
+
+func (Heuristic) Name() string { return HeuristicName }
+
+func (Heuristic) Count(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+var splitters = map[string]func(string) []string{
+	CL100KBase: splitCL100K,
+	O200KBase:  splitO200K,
+}
+
+var (
+	loadedMu sync.Mutex
+	loaded   = map[string]Tokenizer{}
+)
+
+// Get returns the tokenizer registered under name.
+//
+// BPE vocabularies are decoded on first use and cached for the lifetime of
+// the process.
+func Get(name string) (Tokenizer, error) {
+	name = strings.ToLower(name)
+	if name == HeuristicName {
+		return Heuristic{}, nil
+	}
+	split, ok := splitters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+
+	loadedMu.Lock()
+	defer loadedMu.Unlock()
+	if tok, ok := loaded[name]; ok {
+		return tok, nil
+	}
+
+	ranks, err := loadVocab(name)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s vocabulary: %w", name, err)
+	}
+	tok := NewBPE(name, ranks, split)
+	loaded[name] = tok
+	return tok, nil
+}
+
+// Names returns the names of the built-in tokenizers in sorted order.
+func Names() []string {
+	names := []string{HeuristicName}
+	for name := range splitters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadVocab decodes the embedded vocabulary of the named encoding.
+func loadVocab(name string) (map[string]int, error) {
+	file, err := vocabs.Open("vocab/" + name + ".tiktoken.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return LoadRanks(zr)
+}
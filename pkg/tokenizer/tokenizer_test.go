@@ -0,0 +1,122 @@
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeuristic_Count(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "abc", want: 1},
+		{text: "abcd", want: 1},
+		{text: "abcde", want: 2},
+		{text: "日本語です", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, Heuristic{}.Count(tt.text))
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		tokenizer   string
+		text        string
+		want        int
+		expectedErr string
+	}{
+		{
+			name:      "heuristic",
+			tokenizer: HeuristicName,
+			text:      "Hello world",
+			want:      3,
+		},
+		{
+			name:      "embedded cl100k_base",
+			tokenizer: "CL100K_BASE",
+			text:      "Hello world",
+			want:      2,
+		},
+		{
+			name:      "embedded o200k_base",
+			tokenizer: O200KBase,
+			text:      "Hello world",
+			want:      2,
+		},
+		{
+			name:        "unknown tokenizer",
+			tokenizer:   "p50k_base",
+			expectedErr: `unknown tokenizer "p50k_base"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tok, err := Get(tt.tokenizer)
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tok.Count(tt.text))
+		})
+	}
+}
+
+// TestGet_Encodings checks the embedded encodings against token IDs produced
+// by tiktoken.
+func TestGet_Encodings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tokenizer string
+		text      string
+		want      []int
+	}{
+		{tokenizer: CL100KBase, text: "Hello world", want: []int{9906, 1917}},
+		{tokenizer: CL100KBase, text: "tiktoken is great!", want: []int{83, 1609, 5963, 374, 2294, 0}},
+		{tokenizer: CL100KBase, text: "antidisestablishmentarianism", want: []int{519, 85342, 34500, 479, 8997, 2191}},
+		{tokenizer: CL100KBase, text: "2 + 2 = 4", want: []int{17, 489, 220, 17, 284, 220, 19}},
+		{tokenizer: CL100KBase, text: "お誕生日おめでとう", want: []int{33334, 45918, 243, 21990, 9080, 33334, 62004, 16556, 78699}},
+		{tokenizer: O200KBase, text: "Hello world", want: []int{13225, 2375}},
+		{tokenizer: O200KBase, text: "tiktoken is great!", want: []int{83, 8251, 2488, 382, 2212, 0}},
+		{tokenizer: O200KBase, text: "antidisestablishmentarianism", want: []int{493, 129901, 376, 160388, 21203, 2367}},
+		{tokenizer: O200KBase, text: "2 + 2 = 4", want: []int{17, 659, 220, 17, 314, 220, 19}},
+		{tokenizer: O200KBase, text: "お誕生日おめでとう", want: []int{8930, 9697, 243, 128225, 8930, 17693, 4344, 48669}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tokenizer+"/"+tt.text, func(t *testing.T) {
+			t.Parallel()
+
+			tok, err := Get(tt.tokenizer)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tok.(*BPE).Encode(tt.text))
+			assert.Equal(t, len(tt.want), tok.Count(tt.text))
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{CL100KBase, HeuristicName, O200KBase}, Names())
+}